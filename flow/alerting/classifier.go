@@ -73,6 +73,7 @@ type ErrorInfo struct {
 type ErrorClass struct {
 	Class  string
 	action ErrorAction
+	retry  RetryMetadata
 }
 
 var (
@@ -92,7 +93,9 @@ var (
 		Class: "NOTIFY_OOM_SOURCE", action: NotifyUser,
 	}
 	ErrorNotifySlotInvalid = ErrorClass{
-		Class: "NOTIFY_SLOT_INVALID", action: NotifyUser,
+		// An invalidated replication slot can't be fixed by retrying the
+		// same activity; fail fast rather than let Temporal burn attempts.
+		Class: "NOTIFY_SLOT_INVALID", action: NotifyUser, retry: RetryMetadata{NonRetryable: true},
 	}
 	ErrorNotifyBinlogInvalid = ErrorClass{
 		Class: "NOTIFY_BINLOG_INVALID", action: NotifyUser,
@@ -155,6 +158,13 @@ func (e ErrorClass) ErrorAction() ErrorAction {
 }
 
 func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
+	// pgx pipelining/batch execution can wrap one *pgconn.PgError per queued
+	// statement into a single error; classify every one of them rather than
+	// only the first errors.As would find.
+	if class, info, _, ok := classifyMulti(ctx, err); ok {
+		return class, info
+	}
+
 	var pgErr *pgconn.PgError
 	var pgWalErr *exceptions.PostgresWalError
 	if errors.As(err, &pgWalErr) {
@@ -311,6 +321,10 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 		case pgerrcode.QueryCanceled:
 			return ErrorRetryRecoverable, pgErrorInfo
 		}
+
+		if class, ok := classifyByRule(pgErrorInfo, pgErr.Message); ok {
+			return class, pgErrorInfo
+		}
 	}
 
 	var pgConnErr *pgconn.ConnectError
@@ -360,6 +374,9 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 			}
 			return ErrorOther, myErrorInfo
 		default:
+			if class, ok := classifyByRule(myErrorInfo, myErr.Message); ok {
+				return class, myErrorInfo
+			}
 			return ErrorOther, myErrorInfo
 		}
 	}
@@ -445,6 +462,9 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 			if isClickHouseMvError(chException) {
 				return ErrorNotifyMVOrView, chErrorInfo
 			}
+			if class, ok := classifyByRule(chErrorInfo, chException.Message); ok {
+				return class, chErrorInfo
+			}
 			return ErrorOther, chErrorInfo
 		}
 		var normalizationErr *exceptions.NormalizationError
@@ -528,10 +548,14 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 		}
 	}
 
-	return ErrorOther, ErrorInfo{
+	otherErrorInfo := ErrorInfo{
 		Source: ErrorSourceOther,
 		Code:   "UNKNOWN",
 	}
+	if class, ok := classifyByRule(otherErrorInfo, err.Error()); ok {
+		return class, otherErrorInfo
+	}
+	return ErrorOther, otherErrorInfo
 }
 
 func isClickHouseMvError(exception *clickhouse.Exception) bool {
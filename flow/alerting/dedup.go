@@ -0,0 +1,123 @@
+package alerting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reNotifyBackoff is the exponential re-notification schedule for a
+// persisting condition: 1m, 5m, 30m, 6h, holding at 6h for every
+// notification after that.
+var reNotifyBackoff = []time.Duration{
+	time.Minute, 5 * time.Minute, 30 * time.Minute, 6 * time.Hour,
+}
+
+func backoffForNotifyCount(notifyCount int64) time.Duration {
+	if notifyCount < 0 || notifyCount >= int64(len(reNotifyBackoff)) {
+		return reNotifyBackoff[len(reNotifyBackoff)-1]
+	}
+	return reNotifyBackoff[notifyCount]
+}
+
+// Deduper fingerprints classified errors by (mirror, ErrorClass,
+// ErrorSource, Code, normalized message) and suppresses re-notifying for
+// the same fingerprint within its current backoff window, so a flapping
+// condition (WAL segment removed, ClickHouse Keeper hiccups) sends one
+// notification per window instead of one per occurrence. State is kept in
+// the catalog's alert_dedup table, alongside the flows/peers tables.
+type Deduper struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeduper constructs a Deduper backed by pool.
+func NewDeduper(pool *pgxpool.Pool) *Deduper {
+	return &Deduper{pool: pool}
+}
+
+// Fingerprint deterministically identifies a recurring condition,
+// independent of incidental differences (PIDs, LSNs, byte counts) in the
+// raw message.
+func Fingerprint(mirror string, info ErrorInfo, class string, message string) string {
+	h := sha256.New()
+	for _, part := range []string{mirror, class, string(info.Source), info.Code, normalizeMessage(message)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var digitsRe = regexp.MustCompile(`\d+`)
+
+// normalizeMessage strips digit runs from message so two occurrences of the
+// same underlying condition that differ only in those values (a PID, an
+// LSN, a byte count) fingerprint identically.
+func normalizeMessage(message string) string {
+	return digitsRe.ReplaceAllString(strings.TrimSpace(message), "#")
+}
+
+// Summary is the "first seen / last seen / count" rollup ShouldNotify
+// returns, for inclusion in a notification payload once a fingerprint has
+// recurred.
+type Summary struct {
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int64
+}
+
+// ShouldNotify records an occurrence of fingerprint and reports whether a
+// notification should actually be sent now, along with the occurrence's
+// running Summary. A fingerprint not seen before always notifies; after
+// that, it notifies again only once its current backoff interval elapses,
+// and never while an operator's AckError quiet period is still in effect.
+func (d *Deduper) ShouldNotify(ctx context.Context, fingerprint string) (bool, Summary, error) {
+	now := time.Now()
+
+	var summary Summary
+	var notifyCount int64
+	var nextNotifyAt time.Time
+	var ackedUntil *time.Time
+	err := d.pool.QueryRow(ctx, `
+		INSERT INTO alert_dedup (fingerprint, first_seen, last_seen, count, notify_count, next_notify_at)
+		VALUES ($1, $2, $2, 1, 0, $2)
+		ON CONFLICT (fingerprint) DO UPDATE SET
+			last_seen = $2,
+			count = alert_dedup.count + 1
+		RETURNING first_seen, last_seen, count, notify_count, next_notify_at, acked_until
+	`, fingerprint, now).Scan(
+		&summary.FirstSeen, &summary.LastSeen, &summary.Count, &notifyCount, &nextNotifyAt, &ackedUntil,
+	)
+	if err != nil {
+		return false, Summary{}, err
+	}
+
+	if now.Before(nextNotifyAt) || (ackedUntil != nil && now.Before(*ackedUntil)) {
+		return false, summary, nil
+	}
+
+	if _, err := d.pool.Exec(ctx,
+		`UPDATE alert_dedup SET notify_count = notify_count + 1, next_notify_at = $2 WHERE fingerprint = $1`,
+		fingerprint, now.Add(backoffForNotifyCount(notifyCount)),
+	); err != nil {
+		return false, Summary{}, err
+	}
+
+	return true, summary, nil
+}
+
+// AckError silences fingerprint until quietPeriod elapses, even if its
+// backoff window would otherwise have produced another notification
+// sooner. It recurs normally (subject to the usual backoff) once the quiet
+// period passes.
+func (d *Deduper) AckError(ctx context.Context, fingerprint string, quietPeriod time.Duration) error {
+	_, err := d.pool.Exec(ctx,
+		`UPDATE alert_dedup SET acked_until = $2 WHERE fingerprint = $1`,
+		fingerprint, time.Now().Add(quietPeriod),
+	)
+	return err
+}
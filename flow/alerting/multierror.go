@@ -0,0 +1,85 @@
+package alerting
+
+import "context"
+
+// MultiErrorInfo preserves every ErrorInfo classified out of a pgx
+// pipelined or batched error, so downstream telemetry doesn't collapse
+// multiple SQLSTATEs (one per queued statement) into a single one.
+type MultiErrorInfo struct {
+	Infos []ErrorInfo
+}
+
+// GetErrorClassMulti is GetErrorClass's batch-aware counterpart: for a pgx
+// pipeline/batch error wrapping one *pgconn.PgError per queued statement,
+// it classifies every one of them and also returns a MultiErrorInfo
+// recording every ErrorInfo found, not just the one GetErrorClass picks by
+// precedence. For any other error it behaves exactly like GetErrorClass,
+// with a MultiErrorInfo of length 1.
+func GetErrorClassMulti(ctx context.Context, err error) (ErrorClass, ErrorInfo, MultiErrorInfo) {
+	if class, info, multi, ok := classifyMulti(ctx, err); ok {
+		return class, info, multi
+	}
+	class, info := GetErrorClass(ctx, err)
+	return class, info, MultiErrorInfo{Infos: []ErrorInfo{info}}
+}
+
+// unwrapMulti returns the errors joined into err, for any error
+// implementing the `Unwrap() []error` shape the standard library's
+// errors.Join uses — which is also how pgx surfaces a pipeline/batch
+// failure as one error per queued statement.
+func unwrapMulti(err error) ([]error, bool) {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil, false
+	}
+	return joined.Unwrap(), true
+}
+
+// classifyMulti classifies every error unwrapped from a pipeline/batch
+// error via GetErrorClass, returning the most actionable ErrorClass by
+// precedence (NotifyUser > NotifyTelemetry > Ignore — a batch failing for a
+// real, user-actionable reason shouldn't be masked by another queued
+// statement's more benign error) alongside a MultiErrorInfo of every
+// ErrorInfo found. ok is false if err doesn't unwrap into multiple errors.
+func classifyMulti(ctx context.Context, err error) (ErrorClass, ErrorInfo, MultiErrorInfo, bool) {
+	errs, ok := unwrapMulti(err)
+	if !ok {
+		return ErrorClass{}, ErrorInfo{}, MultiErrorInfo{}, false
+	}
+
+	var multi MultiErrorInfo
+	var best ErrorClass
+	var bestInfo ErrorInfo
+	haveBest := false
+
+	for _, sub := range errs {
+		if sub == nil {
+			continue
+		}
+		class, info := GetErrorClass(ctx, sub)
+		multi.Infos = append(multi.Infos, info)
+
+		if !haveBest || actionSeverity(class.ErrorAction()) > actionSeverity(best.ErrorAction()) {
+			best, bestInfo, haveBest = class, info, true
+		}
+	}
+
+	if !haveBest {
+		return ErrorClass{}, ErrorInfo{}, MultiErrorInfo{}, false
+	}
+	return best, bestInfo, multi, true
+}
+
+// actionSeverity orders ErrorAction for classifyMulti's precedence:
+// NotifyUser (a human needs to act) outranks NotifyTelemetry (the worker
+// handles it but wants visibility), which outranks Ignore.
+func actionSeverity(action ErrorAction) int {
+	switch action {
+	case NotifyUser:
+		return 2
+	case NotifyTelemetry:
+		return 1
+	default: // Ignore
+		return 0
+	}
+}
@@ -0,0 +1,112 @@
+package alerting
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// NoticeTelemetryTopic is the topic NoticeHandler publishes every
+// classified NOTICE/NOTIFY on, parallel to how classified errors are
+// reported through the alerting package's existing channels.
+const NoticeTelemetryTopic = "alerts.notice"
+
+// NoticeClass mirrors ErrorClass, but for PostgreSQL NOTICE messages and
+// NOTIFY payloads. pgx surfaces these separately from query errors (via
+// Config.OnNotice/Config.OnNotification), and they carry operator signal
+// GetErrorClass never sees, since no query ever failed.
+type NoticeClass struct {
+	Class  string
+	action ErrorAction
+}
+
+func (n NoticeClass) String() string {
+	return n.Class
+}
+
+func (n NoticeClass) ErrorAction() ErrorAction {
+	if n.action != "" {
+		return n.action
+	}
+	return NotifyTelemetry
+}
+
+var (
+	NoticeIgnoreDebug = NoticeClass{
+		Class: "NOTICE_IGNORE_DEBUG", action: Ignore,
+	}
+	NoticeWalReceiverWarning = NoticeClass{
+		Class: "NOTICE_WAL_RECEIVER_WARNING", action: NotifyTelemetry,
+	}
+	NoticeUserNotify = NoticeClass{
+		Class: "NOTICE_USER_NOTIFY", action: NotifyTelemetry,
+	}
+	NoticeOther = NoticeClass{
+		Class: "NOTICE_OTHER", action: NotifyTelemetry,
+	}
+)
+
+// GetNoticeClass classifies a server NOTICE by its severity and, for a
+// WARNING, its message text. The notice's SQLSTATE is carried in the
+// returned ErrorInfo's Code but does not affect the class.
+func GetNoticeClass(notice *pgconn.Notice) (NoticeClass, ErrorInfo) {
+	info := ErrorInfo{Source: ErrorSourcePostgres, Code: notice.Code}
+
+	switch strings.ToUpper(notice.Severity) {
+	case "DEBUG":
+		return NoticeIgnoreDebug, info
+	case "WARNING":
+		if strings.Contains(notice.Message, "could not receive data from WAL stream") {
+			return NoticeWalReceiverWarning, info
+		}
+		return NoticeOther, info
+	default: // LOG, INFO, and anything else the server sends as a NOTICE
+		return NoticeOther, info
+	}
+}
+
+// NoticeHandler classifies every NOTICE and NOTIFY a *pgconn.PgConn
+// receives and publishes them on NoticeTelemetryTopic, so warnings like
+// "could not receive data from WAL stream" and operator-declared NOTIFY
+// channels (e.g. LISTEN peerdb_ops) are visible without waiting for a query
+// to fail.
+type NoticeHandler struct {
+	// Publish receives every classified notice/notification. Required; a
+	// nil Publish just logs a warning and drops the notice.
+	Publish func(ctx context.Context, topic string, class NoticeClass, info ErrorInfo, payload string)
+	// ListenChannels maps a NOTIFY channel name, declared via the source
+	// peer's LISTEN config, to the ErrorAction its payloads should carry —
+	// e.g. a DBA-triggered `NOTIFY peerdb_ops, 'pause'` can be routed as
+	// NotifyUser instead of the default NotifyTelemetry for unrecognized
+	// channels.
+	ListenChannels map[string]ErrorAction
+}
+
+// Attach registers h as config's OnNotice/OnNotification hooks, so every
+// notice or NOTIFY payload pgx receives over connections made from config is
+// classified and published.
+func (h *NoticeHandler) Attach(config *pgconn.Config) {
+	config.OnNotice = func(_ *pgconn.PgConn, notice *pgconn.Notice) {
+		class, info := GetNoticeClass(notice)
+		h.publish(context.Background(), class, info, notice.Message)
+	}
+	config.OnNotification = func(_ *pgconn.PgConn, notification *pgconn.Notification) {
+		class := NoticeUserNotify
+		if action, ok := h.ListenChannels[notification.Channel]; ok {
+			class = NoticeClass{Class: "NOTICE_LISTEN_" + strings.ToUpper(notification.Channel), action: action}
+		}
+		info := ErrorInfo{Source: ErrorSourcePostgres, Code: notification.Channel}
+		h.publish(context.Background(), class, info, notification.Payload)
+	}
+}
+
+func (h *NoticeHandler) publish(ctx context.Context, class NoticeClass, info ErrorInfo, payload string) {
+	if h.Publish == nil {
+		slog.Warn("dropping postgres notice/notification with no NoticeHandler.Publish configured",
+			slog.String("class", class.String()), slog.String("payload", payload))
+		return
+	}
+	h.Publish(ctx, NoticeTelemetryTopic, class, info, payload)
+}
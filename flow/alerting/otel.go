@@ -0,0 +1,105 @@
+package alerting
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+)
+
+// OpenTelemetry semantic-convention attribute keys RecordOnSpan sets, per
+// https://opentelemetry.io/docs/specs/semconv/database/ and
+// https://opentelemetry.io/docs/specs/semconv/exceptions/.
+const (
+	otelAttrDBSystem         = "db.system"
+	otelAttrDBStatement      = "db.statement"
+	otelAttrDBSQLState       = "db.sql.state"
+	otelAttrErrorType        = "error.type"
+	otelAttrErrorCode        = "error.code"
+	otelAttrMirror           = "peerdb.mirror"
+	otelAttrSourceTable      = "peerdb.source_table"
+	otelAttrDestinationTable = "peerdb.destination_table"
+)
+
+// SpanErrorContext carries the call-site detail RecordOnSpan can't recover
+// from err alone: the mirror and statement that were running, and the
+// source/destination tables involved, whichever are known.
+type SpanErrorContext struct {
+	Mirror           string
+	Statement        string
+	SourceTable      string
+	DestinationTable string
+}
+
+// dbSystem maps an ErrorSource to the OpenTelemetry db.system value for
+// that backend. See
+// https://opentelemetry.io/docs/specs/semconv/database/database-spans/#notes-and-well-known-identifiers-for-dbsystem
+func dbSystem(source ErrorSource) string {
+	switch source {
+	case ErrorSourcePostgres, ErrorSourcePostgresCatalog:
+		return "postgresql"
+	case ErrorSourceMySQL:
+		return "mysql"
+	case ErrorSourceClickHouse:
+		return "clickhouse"
+	default:
+		return ""
+	}
+}
+
+// RecordOnSpan classifies err and records it on the span carried by ctx
+// (status, exception event, and OpenTelemetry semantic-convention
+// attributes), and increments peerdb_errors_total labeled by
+// class/source/code. It's safe to call unconditionally: the trace API's
+// span is a no-op when ctx carries none, and otelManager may be nil when
+// metrics aren't configured.
+func RecordOnSpan(ctx context.Context, otelManager *otel_metrics.OtelManager, errCtx SpanErrorContext, err error) {
+	if err == nil {
+		return
+	}
+
+	class, info := GetErrorClass(ctx, err)
+
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		attrs := []attribute.KeyValue{
+			attribute.String(otelAttrErrorType, class.Class),
+			attribute.String(otelAttrErrorCode, info.Code),
+		}
+		if system := dbSystem(info.Source); system != "" {
+			attrs = append(attrs, attribute.String(otelAttrDBSystem, system), attribute.String(otelAttrDBSQLState, info.Code))
+		}
+		if errCtx.Mirror != "" {
+			attrs = append(attrs, attribute.String(otelAttrMirror, errCtx.Mirror))
+		}
+		if errCtx.Statement != "" {
+			attrs = append(attrs, attribute.String(otelAttrDBStatement, errCtx.Statement))
+		}
+		if errCtx.SourceTable != "" {
+			attrs = append(attrs, attribute.String(otelAttrSourceTable, errCtx.SourceTable))
+		}
+		if errCtx.DestinationTable != "" {
+			attrs = append(attrs, attribute.String(otelAttrDestinationTable, errCtx.DestinationTable))
+		}
+
+		span.SetAttributes(attrs...)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if otelManager == nil {
+		return
+	}
+
+	if counter, cerr := otelManager.GetOrCreateInt64Counter("peerdb_errors_total"); cerr == nil {
+		counter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("class", class.Class),
+			attribute.String("source", string(info.Source)),
+			attribute.String("code", info.Code),
+		))
+	}
+}
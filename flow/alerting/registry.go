@@ -0,0 +1,218 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+// Severity is how prominently a ClassCatalogEntry should be surfaced in the
+// UI, independent of its ErrorAction (which governs what the worker itself
+// does about it).
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// ClassCatalogEntry is the operator-facing description of an ErrorClass:
+// what it means, how to fix it, and where to read more. It's keyed into
+// Registry by ErrorClass.Class so the UI can render a classified error
+// without hardcoding its own copy of this text.
+type ClassCatalogEntry struct {
+	Title          string
+	Remediation    string // markdown
+	DocURL         string
+	Severity       Severity
+	UserActionable bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ClassCatalogEntry{
+		ErrorNotifyPublicationMissing.Class: {
+			Title:          "Publication missing",
+			Remediation:    "The replication publication PeerDB expects no longer exists. Recreate it with `CREATE PUBLICATION <name> FOR ALL TABLES` (or for the specific tables in the mirror) and resume the mirror.",
+			Severity:       SeverityCritical,
+			UserActionable: true,
+		},
+		ErrorNotifySlotInvalid.Class: {
+			Title:          "Replication slot invalidated",
+			Remediation:    "The source's replication slot was invalidated, usually by `max_slot_wal_keep_size` or a dropped subscription. The mirror needs to be re-created from a fresh snapshot.",
+			Severity:       SeverityCritical,
+			UserActionable: true,
+		},
+		ErrorNotifyBinlogInvalid.Class: {
+			Title:          "Binlog position invalid",
+			Remediation:    "The source's binlog position PeerDB was tracking has been purged or rotated past. The mirror needs to be re-created from a fresh snapshot.",
+			Severity:       SeverityCritical,
+			UserActionable: true,
+		},
+		ErrorNotifyOOM.Class: {
+			Title:       "Destination ran out of memory",
+			Remediation: "The destination rejected a query for exceeding its memory limit. Consider reducing batch size or increasing the destination's memory limit.",
+			Severity:    SeverityWarning,
+		},
+		ErrorNotifyOOMSource.Class: {
+			Title:       "Source ran out of memory",
+			Remediation: "The source database ran out of memory while serving PeerDB's query or replication connection. Consider reducing concurrent load on the source.",
+			Severity:    SeverityWarning,
+		},
+		ErrorNotifyConnectivity.Class: {
+			Title:          "Could not connect to peer",
+			Remediation:    "PeerDB could not reach or authenticate to this peer. Check network connectivity, credentials, and connection limits.",
+			Severity:       SeverityCritical,
+			UserActionable: true,
+		},
+		ErrorNotifyMVOrView.Class: {
+			Title:       "Destination materialized view or trigger rejected a write",
+			Remediation: "A materialized view, projection, or trigger on the destination table rejected PeerDB's write. Review the view/trigger definition against the mirrored schema.",
+			Severity:    SeverityWarning,
+		},
+		ErrorNotifyDestinationModified.Class: {
+			Title:          "Destination table modified outside PeerDB",
+			Remediation:    "The destination table or column PeerDB expected no longer matches what it created. Review recent DDL on the destination and re-run schema sync if needed.",
+			Severity:       SeverityCritical,
+			UserActionable: true,
+		},
+		ErrorUnsupportedDatatype.Class: {
+			Title:       "Unsupported data type",
+			Remediation: "A column's data (or its precision/scale) can't be represented on the destination as configured. Consider excluding the column or changing the destination column's type.",
+			Severity:    SeverityWarning,
+		},
+	}
+)
+
+// RegisterClassInfo adds or overrides the ClassCatalogEntry for class. Like
+// RegisterComparator, call it during startup rather than mid-flow.
+func RegisterClassInfo(class string, entry ClassCatalogEntry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[class] = entry
+}
+
+func lookupClassInfo(class string) (ClassCatalogEntry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	entry, ok := registry[class]
+	return entry, ok
+}
+
+// Extras is the typed, class-specific detail GetMirrorErrors surfaces
+// alongside a ClassifiedError's human-readable title and remediation, e.g.
+// the slot or publication name a NOTIFY_SLOT_INVALID/NOTIFY_PUBLICATION_MISSING
+// refers to, or the destination table/column a lossy-conversion error hit.
+type Extras struct {
+	SlotName          string `json:"slotName,omitempty"`
+	PublicationName   string `json:"publicationName,omitempty"`
+	DestinationTable  string `json:"destinationTable,omitempty"`
+	DestinationColumn string `json:"destinationColumn,omitempty"`
+	ClickHouseCode    int32  `json:"clickhouseCode,omitempty"`
+}
+
+// extractExtras pulls whatever typed detail it can out of err's concrete
+// type, best-effort: most error classes have no extras worth surfacing, and
+// that's fine, since Extras's fields are all optional.
+func extractExtras(err error) Extras {
+	var extras Extras
+
+	var chException *clickhouse.Exception
+	if errors.As(err, &chException) {
+		extras.ClickHouseCode = chException.Code
+	}
+
+	var numericOutOfRangeError *exceptions.NumericOutOfRangeError
+	if errors.As(err, &numericOutOfRangeError) {
+		extras.DestinationTable = numericOutOfRangeError.DestinationTable
+		extras.DestinationColumn = numericOutOfRangeError.DestinationColumn
+	}
+
+	var numericTruncatedError *exceptions.NumericTruncatedError
+	if errors.As(err, &numericTruncatedError) {
+		extras.DestinationTable = numericTruncatedError.DestinationTable
+		extras.DestinationColumn = numericTruncatedError.DestinationColumn
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if name, ok := extractQuotedName(pgErr.Message, "publication"); ok {
+			extras.PublicationName = name
+		}
+		if name, ok := extractQuotedName(pgErr.Message, "replication slot"); ok {
+			extras.SlotName = name
+		}
+	}
+
+	return extras
+}
+
+// extractQuotedName pulls the first double-quoted identifier out of a
+// message like `publication "foo" does not exist`, for peers (older
+// pgconn/libpq versions among them) that don't expose the name as a
+// separate structured field.
+func extractQuotedName(message, noun string) (string, bool) {
+	idx := strings.Index(message, noun)
+	if idx < 0 {
+		return "", false
+	}
+	rest := message[idx+len(noun):]
+	start := strings.Index(rest, "\"")
+	if start < 0 {
+		return "", false
+	}
+	rest = rest[start+1:]
+	end := strings.Index(rest, "\"")
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+// ClassifiedError is the enriched record GetMirrorErrors returns to the
+// gRPC/UI layer: a GetErrorClass result joined with its ClassCatalogEntry
+// and extracted Extras, so the UI can render e.g. `Publication "foo"
+// missing — run CREATE PUBLICATION ...` instead of raw Postgres text.
+type ClassifiedError struct {
+	ErrorInfo
+	Class          string
+	Action         ErrorAction
+	Title          string
+	Remediation    string
+	DocURL         string
+	Severity       Severity
+	UserActionable bool
+	Extras         Extras
+	Message        string
+}
+
+// Classify is GetErrorClass's enriched counterpart: it runs the same
+// classification and folds in the ClassCatalogEntry and Extras for err's
+// class, for callers (GetMirrorErrors, the admin CLI) that need operator
+// facing detail rather than just a class string. GetErrorClass itself is
+// left as a compatibility shim for existing callers that only need the bare
+// class/info pair.
+func Classify(ctx context.Context, err error) ClassifiedError {
+	class, info := GetErrorClass(ctx, err)
+
+	entry, _ := lookupClassInfo(class.Class)
+	return ClassifiedError{
+		ErrorInfo:      info,
+		Class:          class.Class,
+		Action:         class.ErrorAction(),
+		Title:          entry.Title,
+		Remediation:    entry.Remediation,
+		DocURL:         entry.DocURL,
+		Severity:       entry.Severity,
+		UserActionable: entry.UserActionable,
+		Extras:         extractExtras(err),
+		Message:        err.Error(),
+	}
+}
@@ -0,0 +1,106 @@
+package alerting
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// RetryMetadata is the structured backoff policy associated with an
+// ErrorClass. Zero value means "use the default for this class's
+// ErrorAction" (see defaultRetryMetadata); set it explicitly on an
+// ErrorClass var to pin a class's policy instead of inheriting that
+// default, the way ErrorNotifySlotInvalid does in classifier.go.
+type RetryMetadata struct {
+	// MaxAttempts is the maximum number of Temporal attempts, including the
+	// first. Zero means unlimited (bounded only by the workflow's own
+	// timeout).
+	MaxAttempts int32
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the exponential backoff coefficient applied after each
+	// attempt. Zero defaults to 2.
+	Multiplier float64
+	// NonRetryable marks the class as fail-fast: Temporal should not retry
+	// at all, since the underlying cause (e.g. an invalidated replication
+	// slot) can't be resolved by trying again.
+	NonRetryable bool
+}
+
+// defaultRetryMetadata picks RetryMetadata from an ErrorClass's ErrorAction
+// when the class hasn't set its own RetryMetadata explicitly.
+func defaultRetryMetadata(action ErrorAction) RetryMetadata {
+	switch action {
+	case NotifyUser:
+		// A human needs to act (fix a permission, recreate a slot, ...);
+		// retrying on our own can't fix that.
+		return RetryMetadata{NonRetryable: true}
+	case Ignore:
+		// Transient and expected; retry a handful of times quickly rather
+		// than escalating.
+		return RetryMetadata{
+			MaxAttempts:    5,
+			InitialBackoff: 250 * time.Millisecond,
+			MaxBackoff:     5 * time.Second,
+			Multiplier:     2,
+		}
+	default: // NotifyTelemetry
+		return RetryMetadata{
+			InitialBackoff: time.Second,
+			MaxBackoff:     2 * time.Minute,
+			Multiplier:     2,
+		}
+	}
+}
+
+// RetryPolicy converts e's RetryMetadata (falling back to the default for
+// e.ErrorAction() if e hasn't set one) into a Temporal RetryPolicy, for
+// activities that want to configure ActivityOptions based on the kind of
+// error a prior attempt returned.
+func (e ErrorClass) RetryPolicy() *temporal.RetryPolicy {
+	retry := e.retryMetadata()
+
+	if retry.NonRetryable {
+		return &temporal.RetryPolicy{MaximumAttempts: 1}
+	}
+
+	multiplier := retry.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	return &temporal.RetryPolicy{
+		InitialInterval:    retry.InitialBackoff,
+		BackoffCoefficient: multiplier,
+		MaximumInterval:    retry.MaxBackoff,
+		MaximumAttempts:    retry.MaxAttempts,
+	}
+}
+
+func (e ErrorClass) retryMetadata() RetryMetadata {
+	if e.retry != (RetryMetadata{}) {
+		return e.retry
+	}
+	return defaultRetryMetadata(e.ErrorAction())
+}
+
+// ClassifyAndWrap classifies err via GetErrorClass and wraps it as a
+// Temporal ApplicationError: Type is set to the matched ErrorClass so
+// workflow history and alerting both see the same classification, and
+// NonRetryable is set from the class's RetryMetadata so e.g.
+// ErrorNotifySlotInvalid fails the activity immediately instead of
+// retrying. Returns nil if err is nil.
+func ClassifyAndWrap(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	class, _ := GetErrorClass(ctx, err)
+	return temporal.NewApplicationErrorWithOptions(err.Error(), class.String(), temporal.ApplicationErrorOptions{
+		NonRetryable: class.retryMetadata().NonRetryable,
+		Cause:        err,
+	})
+}
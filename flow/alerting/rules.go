@@ -0,0 +1,137 @@
+package alerting
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_rules.yaml
+var defaultRulesYAML []byte
+
+// RuleMatch declares what an error must satisfy for its Rule to apply.
+// Every non-empty field must match; an empty field is ignored, so a rule
+// with only MessageRegex set applies regardless of source or code.
+type RuleMatch struct {
+	// Source restricts the rule to a single ErrorSource, e.g. "postgres",
+	// "mysql", "clickhouse".
+	Source ErrorSource `yaml:"source"`
+	// Codes is the set of SQLSTATE/numeric codes the rule applies to.
+	Codes []string `yaml:"codes"`
+	// MessageRegex, if set, must match the error's message.
+	MessageRegex string `yaml:"messageRegex"`
+
+	compiledMessageRegex *regexp.Regexp
+}
+
+func (m RuleMatch) matches(source ErrorSource, code, message string) bool {
+	if m.Source != "" && m.Source != source {
+		return false
+	}
+	if len(m.Codes) > 0 && !slices.Contains(m.Codes, code) {
+		return false
+	}
+	if m.compiledMessageRegex != nil && !m.compiledMessageRegex.MatchString(message) {
+		return false
+	}
+	return true
+}
+
+// Rule maps a RuleMatch to the ErrorClass/ErrorAction GetErrorClass should
+// return for a matching error, in place of a hardcoded switch case.
+type Rule struct {
+	Name   string      `yaml:"name"`
+	Match  RuleMatch   `yaml:"match"`
+	Class  string      `yaml:"class"`
+	Action ErrorAction `yaml:"action"`
+}
+
+type ruleFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+var (
+	rulesMu     sync.RWMutex
+	activeRules []Rule
+)
+
+func init() {
+	rules, err := parseRules(defaultRulesYAML)
+	if err != nil {
+		panic(fmt.Errorf("invalid built-in error classification rules: %w", err))
+	}
+	activeRules = rules
+}
+
+func parseRules(raw []byte) ([]Rule, error) {
+	var file ruleFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+	for i := range file.Rules {
+		if file.Rules[i].Match.MessageRegex != "" {
+			re, err := regexp.Compile(file.Rules[i].Match.MessageRegex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid messageRegex: %w", file.Rules[i].Name, err)
+			}
+			file.Rules[i].Match.compiledMessageRegex = re
+		}
+	}
+	return file.Rules, nil
+}
+
+// ReloadRules loads the YAML ruleset at path and layers it on top of the
+// built-in defaults: rules from path are checked first, so an operator
+// override for a code the defaults already classify takes precedence, while
+// every other default rule keeps applying. Call it again (e.g. on SIGHUP) to
+// pick up edits without restarting the worker.
+func ReloadRules(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading error classification rules from %s: %w", path, err)
+	}
+
+	overrides, err := parseRules(raw)
+	if err != nil {
+		return fmt.Errorf("error parsing error classification rules from %s: %w", path, err)
+	}
+
+	defaults, err := parseRules(defaultRulesYAML)
+	if err != nil {
+		return fmt.Errorf("invalid built-in error classification rules: %w", err)
+	}
+
+	rulesMu.Lock()
+	activeRules = append(overrides, defaults...)
+	rulesMu.Unlock()
+	return nil
+}
+
+// classifyByRule checks (source, code, message) against the active ruleset.
+// GetErrorClass consults it only once its own hardcoded matching falls
+// through without a result, so an operator's ReloadRules override can add a
+// new code (or override an existing one) without a peerdb release.
+func classifyByRule(info ErrorInfo, message string) (ErrorClass, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+
+	for _, rule := range activeRules {
+		if rule.Match.matches(info.Source, info.Code, message) {
+			return ErrorClass{Class: rule.Class, action: rule.Action}, true
+		}
+	}
+	return ErrorClass{}, false
+}
+
+// ClassifyMessage runs the active ruleset against an ad hoc error
+// source/code/message, without needing a real error value. It backs the
+// peerdb-admin classify-error CLI so operators can test a rule (or explain
+// an alert) without reproducing the failure.
+func ClassifyMessage(source ErrorSource, code, message string) (ErrorClass, bool) {
+	return classifyByRule(ErrorInfo{Source: source, Code: code}, message)
+}
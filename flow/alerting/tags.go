@@ -0,0 +1,232 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetTags returns the tag set currently stored for flowName.
+func GetTags(ctx context.Context, pool *pgxpool.Pool, flowName string) (map[string]string, error) {
+	var tags map[string]string
+	if err := pool.QueryRow(ctx, "SELECT tags FROM flows WHERE name=$1", flowName).Scan(&tags); err != nil {
+		return nil, fmt.Errorf("error getting tags for flow %s: %w", flowName, err)
+	}
+	return tags, nil
+}
+
+// PatchTags merges patch into the tag set stored for flowName, adding new
+// keys and overwriting existing ones; keys not present in patch are left
+// untouched. Use DeleteTags to remove a key outright.
+func PatchTags(ctx context.Context, pool *pgxpool.Pool, flowName string, patch map[string]string) error {
+	if len(patch) == 0 {
+		return nil
+	}
+
+	if _, err := pool.Exec(ctx,
+		"UPDATE flows SET tags=coalesce(tags, '{}'::jsonb) || $1, updated_at=now() WHERE name=$2", patch, flowName,
+	); err != nil {
+		return fmt.Errorf("error patching tags for flow %s: %w", flowName, err)
+	}
+	return nil
+}
+
+// DeleteTags removes keys from the tag set stored for flowName. Keys that
+// aren't currently set are ignored.
+func DeleteTags(ctx context.Context, pool *pgxpool.Pool, flowName string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := pool.Exec(ctx,
+		"UPDATE flows SET tags=coalesce(tags, '{}'::jsonb) - $1::text[], updated_at=now() WHERE name=$2", keys, flowName,
+	); err != nil {
+		return fmt.Errorf("error deleting tags for flow %s: %w", flowName, err)
+	}
+	return nil
+}
+
+// TagSelectorOp is the comparison a single TagSelectorTerm applies to a tag
+// value, modeled on Kubernetes label selectors.
+type TagSelectorOp string
+
+const (
+	TagSelectorEquals TagSelectorOp = "="
+	TagSelectorIn     TagSelectorOp = "in"
+	TagSelectorExists TagSelectorOp = "exists"
+)
+
+// TagSelectorTerm matches flows whose tags[Key] satisfies Op against Values:
+// TagSelectorEquals requires tags[Key] == Values[0], TagSelectorIn requires
+// tags[Key] to be one of Values, and TagSelectorExists only requires Key to
+// be present (Values is ignored).
+type TagSelectorTerm struct {
+	Key    string
+	Op     TagSelectorOp
+	Values []string
+}
+
+func (t TagSelectorTerm) matches(tags map[string]string) bool {
+	value, ok := tags[t.Key]
+	switch t.Op {
+	case TagSelectorExists:
+		return ok
+	case TagSelectorEquals:
+		return ok && len(t.Values) == 1 && value == t.Values[0]
+	case TagSelectorIn:
+		if !ok {
+			return false
+		}
+		for _, candidate := range t.Values {
+			if value == candidate {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// TagSelector is a conjunction of TagSelectorTerms: a flow matches only if
+// every term matches, mirroring how Kubernetes combines comma-separated
+// label selector terms.
+type TagSelector []TagSelectorTerm
+
+// Matches reports whether every term in s matches tags.
+func (s TagSelector) Matches(tags map[string]string) bool {
+	for _, term := range s {
+		if !term.matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTagSelector parses a comma-separated selector of the form
+// "env=prod,team in (payments,risk),on-call" into a TagSelector, supporting
+// the same three term shapes as Kubernetes label selectors:
+//
+//	key=value       TagSelectorEquals
+//	key in (v1,v2)  TagSelectorIn
+//	key             TagSelectorExists
+func ParseTagSelector(raw string) (TagSelector, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	// split on commas outside parentheses, so "team in (payments,risk)"
+	// stays a single term
+	var terms []string
+	depth, start := 0, 0
+	for i, r := range raw {
+		if r == '(' {
+			depth++
+		} else if r == ')' {
+			depth--
+		} else if r == ',' && depth == 0 {
+			terms = append(terms, raw[start:i])
+			start = i + 1
+		}
+	}
+	terms = append(terms, raw[start:])
+
+	var selector TagSelector
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		if key, rest, found := strings.Cut(term, "="); found {
+			selector = append(selector, TagSelectorTerm{
+				Key:    strings.TrimSpace(key),
+				Op:     TagSelectorEquals,
+				Values: []string{strings.TrimSpace(rest)},
+			})
+			continue
+		}
+
+		if key, rest, found := strings.Cut(term, " in "); found {
+			rest = strings.TrimSpace(rest)
+			rest = strings.TrimPrefix(rest, "(")
+			rest = strings.TrimSuffix(rest, ")")
+			values := strings.Split(rest, "|")
+			if len(values) == 1 {
+				values = strings.Split(rest, ",")
+			}
+			for i, v := range values {
+				values[i] = strings.TrimSpace(v)
+			}
+			selector = append(selector, TagSelectorTerm{
+				Key:    strings.TrimSpace(key),
+				Op:     TagSelectorIn,
+				Values: values,
+			})
+			continue
+		}
+
+		selector = append(selector, TagSelectorTerm{Key: term, Op: TagSelectorExists})
+	}
+
+	return selector, nil
+}
+
+// ListFlowNamesByTag returns the names of every flow whose tags satisfy
+// selector. It scans every flow's tags rather than pushing the selector into
+// SQL, since the selector's "in"/"exists" shapes don't map cleanly onto a
+// single jsonb operator; this is fine at the scale of a peerdb catalog's
+// flows table.
+func ListFlowNamesByTag(ctx context.Context, pool *pgxpool.Pool, selector TagSelector) ([]string, error) {
+	rows, err := pool.Query(ctx, "SELECT name, coalesce(tags, '{}'::jsonb) FROM flows")
+	if err != nil {
+		return nil, fmt.Errorf("error listing flows for tag selector: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		var tags map[string]string
+		if err := rows.Scan(&name, &tags); err != nil {
+			return nil, fmt.Errorf("error scanning flow tags: %w", err)
+		}
+		if selector.Matches(tags) {
+			names = append(names, name)
+		}
+	}
+	return names, rows.Err()
+}
+
+// AlertRoute binds a TagSelector to the alert channels that should receive
+// notifications for any flow matching it, so an operator can route alerts by
+// tag (e.g. "env=prod,team=payments") instead of listing flow names in every
+// channel's config.
+type AlertRoute struct {
+	Selector TagSelector
+	Channels []string
+}
+
+// MatchRoutes returns the deduplicated union of channels across every route
+// in routes whose Selector matches tags, preserving each channel's first
+// occurrence order.
+func MatchRoutes(routes []AlertRoute, tags map[string]string) []string {
+	var channels []string
+	seen := make(map[string]struct{})
+	for _, route := range routes {
+		if !route.Selector.Matches(tags) {
+			continue
+		}
+		for _, channel := range route.Channels {
+			if _, ok := seen[channel]; ok {
+				continue
+			}
+			seen[channel] = struct{}{}
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
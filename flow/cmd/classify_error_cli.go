@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+)
+
+// NewClassifyErrorCommand builds the `peerdb-admin classify-error` command,
+// which runs the active error classification ruleset (built-in defaults
+// plus whatever ReloadRules has loaded) against an ad hoc error string, so
+// an operator can test a new rule, or explain an alert, without reproducing
+// the underlying failure.
+func NewClassifyErrorCommand() *cobra.Command {
+	var source string
+	var code string
+
+	cmd := &cobra.Command{
+		Use:   "classify-error <message>",
+		Short: "Classify a raw error message using the active error classification ruleset",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			class, ok := alerting.ClassifyMessage(alerting.ErrorSource(source), code, args[0])
+			if !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "no rule matched; GetErrorClass's built-in matching is not run by this command")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (action=%s)\n", class.String(), class.ErrorAction())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&source, "source", "", "error source, e.g. postgres, mysql, clickhouse")
+	cmd.Flags().StringVar(&code, "code", "", "SQLSTATE or numeric error code")
+
+	return cmd
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// GetMirrorErrors returns the UI-facing classification of up to in.Limit of
+// the most recent errors recorded in in.FlowName's flow_errors rows, newest
+// first. Each stored message is re-classified as a plain error string, so
+// it gets the source "other" and code "UNKNOWN" that GetErrorClass gives an
+// error of no known type: Postgres/MySQL/ClickHouse type and code matching
+// is not applied, and no Extras are recovered from the stored text.
+func (h *FlowRequestHandler) GetMirrorErrors(
+	ctx context.Context,
+	in *protos.GetMirrorErrorsRequest,
+) (*protos.GetMirrorErrorsResponse, error) {
+	flowName := in.FlowName
+
+	if exists, err := h.flowExists(ctx, flowName); err != nil {
+		return nil, err
+	} else if !exists {
+		slog.Error("flow does not exist", slog.String("flow_name", flowName))
+		return nil, fmt.Errorf("flow %s does not exist", flowName)
+	}
+
+	rows, err := h.pool.Query(ctx,
+		`SELECT error_message, occurred_at FROM flow_errors WHERE flow_name=$1 ORDER BY occurred_at DESC LIMIT $2`,
+		flowName, in.Limit)
+	if err != nil {
+		slog.Error("error listing mirror errors", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classified []*protos.ClassifiedError
+	for rows.Next() {
+		var rawMessage string
+		var occurredAt int64
+		if err := rows.Scan(&rawMessage, &occurredAt); err != nil {
+			return nil, fmt.Errorf("error scanning mirror error row: %w", err)
+		}
+
+		class := alerting.Classify(ctx, errors.New(rawMessage))
+		classified = append(classified, &protos.ClassifiedError{
+			Class:          class.Class,
+			Action:         class.Action.String(),
+			Title:          class.Title,
+			Remediation:    class.Remediation,
+			DocUrl:         class.DocURL,
+			Severity:       string(class.Severity),
+			UserActionable: class.UserActionable,
+			Message:        class.Message,
+			OccurredAt:     occurredAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading mirror errors for flow %s: %w", flowName, err)
+	}
+
+	return &protos.GetMirrorErrorsResponse{
+		FlowName: flowName,
+		Errors:   classified,
+	}, nil
+}
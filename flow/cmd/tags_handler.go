@@ -74,3 +74,82 @@ func (h *FlowRequestHandler) GetFlowTags(ctx context.Context, in *protos.GetFlow
 		Tags:     protosTags,
 	}, nil
 }
+
+// PatchFlowTags applies a partial update to a flow's tags: keys present in
+// the request are added or overwritten, and every other existing key is left
+// untouched. Use DeleteFlowTags to remove a key outright.
+func (h *FlowRequestHandler) PatchFlowTags(
+	ctx context.Context,
+	in *protos.PatchFlowTagsRequest,
+) (*protos.PatchFlowTagsResponse, error) {
+	flowName := in.FlowName
+
+	if exists, err := h.flowExists(ctx, flowName); err != nil {
+		return nil, err
+	} else if !exists {
+		slog.Error("flow does not exist", slog.String("flow_name", flowName))
+		return nil, fmt.Errorf("flow %s does not exist", flowName)
+	}
+
+	patch := make(map[string]string, len(in.Tags))
+	for _, tag := range in.Tags {
+		patch[tag.Key] = tag.Value
+	}
+
+	if err := alerting.PatchTags(ctx, h.pool, flowName, patch); err != nil {
+		slog.Error("error patching flow tags", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &protos.PatchFlowTagsResponse{
+		FlowName: flowName,
+	}, nil
+}
+
+// DeleteFlowTags removes the given tag keys from a flow, leaving every other
+// tag untouched.
+func (h *FlowRequestHandler) DeleteFlowTags(
+	ctx context.Context,
+	in *protos.DeleteFlowTagsRequest,
+) (*protos.DeleteFlowTagsResponse, error) {
+	flowName := in.FlowName
+
+	if exists, err := h.flowExists(ctx, flowName); err != nil {
+		return nil, err
+	} else if !exists {
+		slog.Error("flow does not exist", slog.String("flow_name", flowName))
+		return nil, fmt.Errorf("flow %s does not exist", flowName)
+	}
+
+	if err := alerting.DeleteTags(ctx, h.pool, flowName, in.Keys); err != nil {
+		slog.Error("error deleting flow tags", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &protos.DeleteFlowTagsResponse{
+		FlowName: flowName,
+	}, nil
+}
+
+// ListFlowsByTag returns the names of every flow whose tags satisfy in.Selector,
+// a Kubernetes-style label selector (e.g. "env=prod,team in (payments,risk)").
+func (h *FlowRequestHandler) ListFlowsByTag(
+	ctx context.Context,
+	in *protos.ListFlowsByTagRequest,
+) (*protos.ListFlowsByTagResponse, error) {
+	selector, err := alerting.ParseTagSelector(in.Selector)
+	if err != nil {
+		slog.Error("error parsing tag selector", slog.String("selector", in.Selector), slog.Any("error", err))
+		return nil, fmt.Errorf("invalid tag selector %q: %w", in.Selector, err)
+	}
+
+	flowNames, err := alerting.ListFlowNamesByTag(ctx, h.pool, selector)
+	if err != nil {
+		slog.Error("error listing flows by tag", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &protos.ListFlowsByTagResponse{
+		FlowNames: flowNames,
+	}, nil
+}
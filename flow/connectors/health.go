@@ -0,0 +1,171 @@
+package connectors
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// HealthResult is the cached outcome of the most recent HealthMonitor probe
+// of a peer, suitable for serving directly from a /api/v1/peers/:name/health
+// endpoint without blocking on a live probe.
+type HealthResult struct {
+	Status    shared.HealthStatus
+	Err       error
+	CheckedAt time.Time
+}
+
+// HealthMonitor periodically probes every peer registered in the catalog
+// that implements HealthCheckConnector, publishing the results as otel
+// gauges and caching the latest result per peer. It reuses the same
+// connector pool as regular activities (via Manager), so its probes don't
+// open a fresh TCP/TLS session (or SSH tunnel) on every tick.
+type HealthMonitor struct {
+	catalogPool shared.CatalogPool
+	manager     *Manager
+	otelManager *otel_metrics.OtelManager
+	interval    time.Duration
+
+	mu      sync.RWMutex
+	results map[string]HealthResult
+}
+
+// NewHealthMonitor constructs a HealthMonitor that probes every peer in
+// catalogPool's peers table every interval, leasing connectors through
+// manager and publishing gauges through otelManager (which may be nil, in
+// which case results are still cached but nothing is exported).
+func NewHealthMonitor(
+	catalogPool shared.CatalogPool, manager *Manager, otelManager *otel_metrics.OtelManager, interval time.Duration,
+) *HealthMonitor {
+	return &HealthMonitor{
+		catalogPool: catalogPool,
+		manager:     manager,
+		otelManager: otelManager,
+		interval:    interval,
+		results:     make(map[string]HealthResult),
+	}
+}
+
+// Run blocks, probing every registered peer every h.interval, until ctx is
+// cancelled. Callers typically run it in its own goroutine for the lifetime
+// of a worker process.
+func (h *HealthMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Get returns the most recent HealthResult cached for peerName, if any.
+func (h *HealthMonitor) Get(peerName string) (HealthResult, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result, ok := h.results[peerName]
+	return result, ok
+}
+
+// checkAll fans out a HealthCheck to every registered peer concurrently,
+// caching and publishing whatever each one returns. A single peer's probe
+// failing (connector doesn't implement HealthCheckConnector, or the probe
+// itself errors) doesn't stop the others from completing.
+func (h *HealthMonitor) checkAll(ctx context.Context) {
+	logger := internal.LoggerFromCtx(ctx)
+
+	peerNames, err := h.listPeerNames(ctx)
+	if err != nil {
+		logger.Error("health monitor failed to list peers", slog.Any("error", err))
+		return
+	}
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, peerName := range peerNames {
+		eg.Go(func() error {
+			h.checkOne(egCtx, peerName)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+}
+
+func (h *HealthMonitor) listPeerNames(ctx context.Context) ([]string, error) {
+	rows, err := h.catalogPool.Query(ctx, "SELECT name FROM peers")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (h *HealthMonitor) checkOne(ctx context.Context, peerName string) {
+	logger := internal.LoggerFromCtx(ctx)
+
+	conn, release, err := GetByNameAsManaged[HealthCheckConnector](ctx, h.manager, nil, peerName)
+	if err != nil {
+		h.record(ctx, peerName, shared.HealthStatus{}, err)
+		return
+	}
+	defer release()
+
+	status, err := conn.HealthCheck(ctx)
+	if err != nil {
+		logger.Warn("peer health check failed", slog.String("peerName", peerName), slog.Any("error", err))
+	}
+	h.record(ctx, peerName, status, err)
+}
+
+func (h *HealthMonitor) record(ctx context.Context, peerName string, status shared.HealthStatus, err error) {
+	h.mu.Lock()
+	h.results[peerName] = HealthResult{Status: status, Err: err, CheckedAt: time.Now()}
+	h.mu.Unlock()
+
+	if h.otelManager == nil {
+		return
+	}
+
+	attrs := metric.WithAttributes(attribute.String("peer_name", peerName))
+
+	if upGauge, gerr := h.otelManager.GetOrCreateFloat64Gauge("peerdb.peer.up"); gerr == nil {
+		up := 0.0
+		if status.Up {
+			up = 1.0
+		}
+		upGauge.Record(ctx, up, attrs)
+	}
+
+	if !status.Up {
+		return
+	}
+
+	if latencyGauge, gerr := h.otelManager.GetOrCreateFloat64Gauge("peerdb.peer.latency_ms"); gerr == nil {
+		latencyGauge.Record(ctx, float64(status.Latency.Milliseconds()), attrs)
+	}
+	if lagGauge, gerr := h.otelManager.GetOrCreateFloat64Gauge("peerdb.peer.replication_lag_bytes"); gerr == nil {
+		lagGauge.Record(ctx, float64(status.ReplicationLagBytes), attrs)
+	}
+}
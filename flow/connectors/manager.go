@@ -0,0 +1,271 @@
+package connectors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+// ManagerOptions tunes how long a Manager keeps a leased connector (and its
+// tunnel, if any) alive once idle, how long it's kept at all, and how many
+// distinct peer connections it will hold open concurrently.
+type ManagerOptions struct {
+	// IdleTTL is how long an unleased connector is kept warm before being
+	// closed. Zero closes it as soon as its last lease is released.
+	IdleTTL time.Duration
+	// MaxLifetime is the maximum age of a cached connector: once it's older
+	// than that and has no outstanding leases, the next lease tears it down
+	// and builds a fresh one. A connector that is still leased keeps being
+	// reused. Zero means no forced recycling.
+	MaxLifetime time.Duration
+	// MaxConcurrentLeases caps the number of distinct (peer, env) connectors
+	// the Manager will hold open at once. Zero means unbounded.
+	MaxConcurrentLeases int
+}
+
+// DefaultManagerOptions returns the options a worker process should use
+// absent any peer-specific overrides.
+func DefaultManagerOptions() ManagerOptions {
+	return ManagerOptions{
+		IdleTTL:     2 * time.Minute,
+		MaxLifetime: 30 * time.Minute,
+	}
+}
+
+type managerKey struct {
+	peerName       string
+	envFingerprint string
+}
+
+type managedEntry struct {
+	conn      Connector
+	tunnel    Tunnel
+	refCount  int
+	createdAt time.Time
+	idleTimer *time.Timer
+}
+
+// Manager hands out reference-counted, cached Connector instances keyed by
+// peer name and env fingerprint, so that temporal activities running in the
+// same worker process share underlying connection resources (TCP/TLS
+// sessions, SSH tunnels) instead of reopening them on every activity call.
+// The Manager owns each connector's tunnel, tearing it down only once the
+// last lease referencing it has been released.
+type Manager struct {
+	mu          sync.Mutex
+	catalogPool shared.CatalogPool
+	options     ManagerOptions
+	entries     map[managerKey]*managedEntry
+}
+
+// NewManager constructs a Manager. catalogPool is used to resolve peer
+// configs (and any SSHConfig tunnel) on a cache miss.
+func NewManager(catalogPool shared.CatalogPool, options ManagerOptions) *Manager {
+	return &Manager{
+		catalogPool: catalogPool,
+		options:     options,
+		entries:     make(map[managerKey]*managedEntry),
+	}
+}
+
+// Lease returns a cached Connector for name (creating and caching one if
+// necessary), along with a release func the caller must call exactly once
+// when done. The connector and its tunnel, if any, are not closed until the
+// last outstanding lease is released and, if IdleTTL is set, that TTL elapses
+// without a new lease being taken.
+func (m *Manager) Lease(ctx context.Context, env map[string]string, peerName string) (Connector, func(), error) {
+	key := managerKey{peerName: peerName, envFingerprint: envFingerprint(env)}
+
+	m.mu.Lock()
+	if entry, ok := m.entries[key]; ok {
+		if m.options.MaxLifetime <= 0 || time.Since(entry.createdAt) < m.options.MaxLifetime || entry.refCount > 0 {
+			entry.refCount++
+			if entry.idleTimer != nil {
+				entry.idleTimer.Stop()
+				entry.idleTimer = nil
+			}
+			m.mu.Unlock()
+			return entry.conn, m.releaseFunc(key), nil
+		}
+		m.closeEntryLocked(ctx, key, entry)
+	}
+	if m.options.MaxConcurrentLeases > 0 && len(m.entries) >= m.options.MaxConcurrentLeases {
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("connector manager: max concurrent leases (%d) reached", m.options.MaxConcurrentLeases)
+	}
+	m.mu.Unlock()
+
+	peer, err := LoadPeer(ctx, m.catalogPool, peerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tunnel, err := m.dialTunnel(ctx, peer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := GetConnector(ctx, env, peer)
+	if err != nil {
+		tunnel.Close()
+		return nil, nil, exceptions.NewPeerCreateError(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// another goroutine may have raced us to populate this key; prefer the
+	// entry already cached and close what we just opened.
+	if entry, ok := m.entries[key]; ok {
+		entry.refCount++
+		conn.Close()
+		tunnel.Close()
+		return entry.conn, m.releaseFunc(key), nil
+	}
+
+	m.entries[key] = &managedEntry{
+		conn:      conn,
+		tunnel:    tunnel,
+		refCount:  1,
+		createdAt: time.Now(),
+	}
+	return conn, m.releaseFunc(key), nil
+}
+
+func (m *Manager) releaseFunc(key managerKey) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			entry, ok := m.entries[key]
+			if !ok {
+				return
+			}
+			entry.refCount--
+			if entry.refCount > 0 {
+				return
+			}
+
+			if m.options.IdleTTL <= 0 {
+				m.closeEntryLocked(context.Background(), key, entry)
+				return
+			}
+			entry.idleTimer = time.AfterFunc(m.options.IdleTTL, func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				if current, ok := m.entries[key]; ok && current == entry && entry.refCount == 0 {
+					m.closeEntryLocked(context.Background(), key, entry)
+				}
+			})
+		})
+	}
+}
+
+// closeEntryLocked removes key from the cache and tears down its connector
+// and tunnel. m.mu must be held by the caller.
+func (m *Manager) closeEntryLocked(ctx context.Context, key managerKey, entry *managedEntry) {
+	delete(m.entries, key)
+	if entry.idleTimer != nil {
+		entry.idleTimer.Stop()
+	}
+	CloseConnector(ctx, entry.conn)
+	if err := entry.tunnel.Close(); err != nil {
+		internal.LoggerFromCtx(ctx).Error("error closing connector tunnel",
+			slog.String("peerName", key.peerName), slog.Any("error", err))
+	}
+}
+
+// dialTunnel returns a Tunnel for peer: a direct dialer unless the peer
+// carries an SSHConfig, in which case an SSH-bastion tunnel is established.
+// Lease only keeps the tunnel alongside the connector; the connector does
+// not dial through it.
+func (m *Manager) dialTunnel(ctx context.Context, peer *protos.Peer) (Tunnel, error) {
+	sshConfig := peerSSHConfig(peer)
+	if sshConfig == nil {
+		return newDirectTunnel(), nil
+	}
+	return newSSHTunnel(ctx, sshConfig)
+}
+
+// peerSSHConfig extracts the optional SSHConfig carried by a peer's config,
+// for peer types that support being reached through a bastion.
+func peerSSHConfig(peer *protos.Peer) *protos.SSHConfig {
+	switch inner := peer.Config.(type) {
+	case *protos.Peer_PostgresConfig:
+		return inner.PostgresConfig.SshConfig
+	case *protos.Peer_MysqlConfig:
+		return inner.MysqlConfig.SshConfig
+	case *protos.Peer_MongoConfig:
+		return inner.MongoConfig.SshConfig
+	case *protos.Peer_ClickhouseConfig:
+		return inner.ClickhouseConfig.SshConfig
+	case *protos.Peer_KafkaConfig:
+		return inner.KafkaConfig.SshConfig
+	default:
+		return nil
+	}
+}
+
+// Close releases every cached connector and tunnel regardless of refcount,
+// for use when a worker process is shutting down.
+func (m *Manager) Close(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		m.closeEntryLocked(ctx, key, entry)
+	}
+}
+
+// envFingerprint derives a stable cache-key component from an activity's env
+// overrides, so connectors configured with different dynamic settings (e.g.
+// a per-mirror statement timeout) aren't incorrectly shared.
+func envFingerprint(env map[string]string) string {
+	if len(env) == 0 {
+		return ""
+	}
+	keys := slices.Sorted(maps.Keys(env))
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(env[k])
+		sb.WriteByte('\x00')
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetByNameAsManaged is GetByNameAs rerouted through a Manager: it returns a
+// cached, reference-counted connector instead of constructing a fresh one,
+// along with a release func the caller must invoke instead of CloseConnector
+// when done with it.
+func GetByNameAsManaged[T Connector](
+	ctx context.Context, m *Manager, env map[string]string, peerName string,
+) (T, func(), error) {
+	var none T
+	conn, release, err := m.Lease(ctx, env, peerName)
+	if err != nil {
+		return none, nil, err
+	}
+
+	tconn, ok := conn.(T)
+	if !ok {
+		release()
+		return none, nil, errors.ErrUnsupported
+	}
+	return tconn, release, nil
+}
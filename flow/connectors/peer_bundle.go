@@ -0,0 +1,205 @@
+package connectors
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// peerBundleTTL bounds how long an exported bundle remains importable, so a
+// token that leaks into shell history or a CI log is useless shortly after.
+const peerBundleTTL = 10 * time.Minute
+
+// peerBundlePayload is the signed, then encrypted, contents of a peer
+// bundle token. It's gob-encoded rather than a protobuf message since it
+// never needs to be read by anything other than ExportPeerBundle/
+// ImportPeerBundle themselves.
+type peerBundlePayload struct {
+	DBType     protos.DBType
+	PeerConfig []byte // marshalled protos.Peer
+	Issuer     string
+	Audience   string // hex-encoded recipient public key
+	NotBefore  time.Time
+	NotAfter   time.Time
+	Nonce      [16]byte
+}
+
+// peerBundleToken is what actually travels between deployments: a
+// box.Seal'd (to the recipient's public key) envelope containing the
+// gob-encoded payload and the exporter's signature over it.
+type peerBundleToken struct {
+	SenderPublicKey [32]byte
+	Nonce           [24]byte
+	Sealed          []byte // box.Seal(gob(signedPayload))
+}
+
+type signedPayload struct {
+	Payload   []byte
+	Signature []byte
+}
+
+// ExportPeerBundle packages peerName's resolved config into an opaque,
+// signed-and-encrypted token addressed to recipientPubKey, so it can be
+// handed to another PeerDB deployment (e.g. to promote a peer from staging
+// to prod) without ever putting the raw credentials on the wire or in shell
+// history.
+func ExportPeerBundle(
+	ctx context.Context, catalogPool shared.CatalogPool, peerName string, recipientPubKey [32]byte,
+) ([]byte, error) {
+	peer, err := LoadPeer(ctx, catalogPool, peerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peer for export: %w", err)
+	}
+
+	peerBytes, err := proto.Marshal(peer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal peer config for export: %w", err)
+	}
+
+	signingKey, issuer, err := internal.SigningKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peer bundle signing key: %w", err)
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate peer bundle nonce: %w", err)
+	}
+
+	now := time.Now()
+	payload := peerBundlePayload{
+		DBType:     peer.Type,
+		PeerConfig: peerBytes,
+		Issuer:     issuer,
+		Audience:   hex.EncodeToString(recipientPubKey[:]),
+		NotBefore:  now,
+		NotAfter:   now.Add(peerBundleTTL),
+		Nonce:      nonce,
+	}
+
+	var payloadBuf bytes.Buffer
+	if err := gob.NewEncoder(&payloadBuf).Encode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to encode peer bundle payload: %w", err)
+	}
+
+	signed := signedPayload{
+		Payload:   payloadBuf.Bytes(),
+		Signature: ed25519.Sign(signingKey, payloadBuf.Bytes()),
+	}
+
+	var signedBuf bytes.Buffer
+	if err := gob.NewEncoder(&signedBuf).Encode(&signed); err != nil {
+		return nil, fmt.Errorf("failed to encode signed peer bundle: %w", err)
+	}
+
+	senderPub, senderPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate peer bundle sender key: %w", err)
+	}
+	var nonce24 [24]byte
+	if _, err := rand.Read(nonce24[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate peer bundle encryption nonce: %w", err)
+	}
+
+	sealed := box.Seal(nil, signedBuf.Bytes(), &nonce24, &recipientPubKey, senderPriv)
+
+	token := peerBundleToken{
+		SenderPublicKey: *senderPub,
+		Nonce:           nonce24,
+		Sealed:          sealed,
+	}
+	var tokenBuf bytes.Buffer
+	if err := gob.NewEncoder(&tokenBuf).Encode(&token); err != nil {
+		return nil, fmt.Errorf("failed to encode peer bundle token: %w", err)
+	}
+	return tokenBuf.Bytes(), nil
+}
+
+// ImportPeerBundle verifies and decrypts a token produced by ExportPeerBundle,
+// returning the peer it describes. It rejects tokens that are expired, not
+// yet valid, addressed to a different recipient, signed by an untrusted
+// issuer, or whose nonce has been seen before (replay). Callers are
+// responsible for inserting the returned peer, same as any other *protos.Peer
+// obtained via LoadPeer.
+func ImportPeerBundle(
+	ctx context.Context, catalogPool shared.CatalogPool, recipientPriv [32]byte, token []byte,
+) (*protos.Peer, error) {
+	var bundleToken peerBundleToken
+	if err := gob.NewDecoder(bytes.NewReader(token)).Decode(&bundleToken); err != nil {
+		return nil, fmt.Errorf("failed to decode peer bundle token: %w", err)
+	}
+
+	opened, ok := box.Open(nil, bundleToken.Sealed, &bundleToken.Nonce, &bundleToken.SenderPublicKey, &recipientPriv)
+	if !ok {
+		return nil, errors.New("failed to decrypt peer bundle: not addressed to this recipient")
+	}
+
+	var signed signedPayload
+	if err := gob.NewDecoder(bytes.NewReader(opened)).Decode(&signed); err != nil {
+		return nil, fmt.Errorf("failed to decode peer bundle contents: %w", err)
+	}
+
+	var payload peerBundlePayload
+	if err := gob.NewDecoder(bytes.NewReader(signed.Payload)).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode peer bundle payload: %w", err)
+	}
+
+	issuerKey, err := internal.TrustedSigningKey(ctx, payload.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up peer bundle issuer %q: %w", payload.Issuer, err)
+	}
+	if !ed25519.Verify(issuerKey, signed.Payload, signed.Signature) {
+		return nil, fmt.Errorf("peer bundle signature from issuer %q does not verify", payload.Issuer)
+	}
+
+	var recipientPub [32]byte
+	curve25519.ScalarBaseMult(&recipientPub, &recipientPriv)
+	if payload.Audience != hex.EncodeToString(recipientPub[:]) {
+		return nil, errors.New("peer bundle is not addressed to this recipient's audience")
+	}
+
+	now := time.Now()
+	if now.Before(payload.NotBefore) {
+		return nil, errors.New("peer bundle is not yet valid")
+	}
+	if now.After(payload.NotAfter) {
+		return nil, errors.New("peer bundle has expired")
+	}
+
+	if err := reserveBundleNonce(ctx, catalogPool, payload.Issuer, payload.Nonce); err != nil {
+		return nil, err
+	}
+
+	var peer protos.Peer
+	if err := proto.Unmarshal(payload.PeerConfig, &peer); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal peer config from bundle: %w", err)
+	}
+	return &peer, nil
+}
+
+// reserveBundleNonce records issuer/nonce as consumed, returning an error if
+// it was already present, so a captured token can't be replayed to import
+// the same peer a second time.
+func reserveBundleNonce(ctx context.Context, catalogPool shared.CatalogPool, issuer string, nonce [16]byte) error {
+	_, err := catalogPool.Exec(ctx, `
+		INSERT INTO peer_bundle_nonces (issuer, nonce, seen_at)
+		VALUES ($1, $2, now())`, issuer, nonce[:])
+	if err != nil {
+		return fmt.Errorf("peer bundle rejected: nonce already used (possible replay): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+const (
+	// rawTableBatchMaxTimestampSQL finds the newest batch whose rows are all
+	// older than a retention cutoff, so archival only ever touches batches
+	// entirely outside the retention window.
+	rawTableBatchMaxTimestampSQL = `SELECT COALESCE(MAX(_PEERDB_BATCH_ID), 0) FROM (SELECT _PEERDB_BATCH_ID FROM %s.%s
+		GROUP BY _PEERDB_BATCH_ID HAVING MAX(_PEERDB_TIMESTAMP) < %d)`
+	archiveRawTableRangeSQL = `DELETE FROM %s.%s WHERE _PEERDB_BATCH_ID > %d AND _PEERDB_BATCH_ID <= %d`
+	// copyRawBatchRangeToStageSQL exports a batch range straight from
+	// Snowflake to the mirror's existing stage via COPY INTO, so archival
+	// doesn't need to round-trip rows through this process the way the Avro
+	// sync path does for data coming from the source side.
+	copyRawBatchRangeToStageSQL = `COPY INTO @%s/archive/%s/%d_%d FROM (
+		SELECT * FROM %s.%s WHERE _PEERDB_BATCH_ID > %d AND _PEERDB_BATCH_ID <= %d
+	) FILE_FORMAT = (TYPE = PARQUET) HEADER = TRUE OVERWRITE = TRUE`
+)
+
+// archiveAfterNormalize runs archival as a best-effort cleanup once
+// NormalizeRecords has successfully advanced the normalize checkpoint for
+// flowJobName: it's not on the critical path, so a failure here is logged
+// and swallowed rather than failing the normalize activity outright.
+func (c *SnowflakeConnector) archiveAfterNormalize(ctx context.Context, flowJobName string) {
+	if c.config.Retention == nil || c.config.Retention.RetentionSeconds <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-time.Duration(c.config.Retention.RetentionSeconds) * time.Second)
+	beforeBatchID, err := c.retentionCutoffBatchID(ctx, flowJobName, cutoff)
+	if err != nil {
+		c.logger.Error("failed to compute raw table retention cutoff", "flowName", flowJobName, "error", err)
+		return
+	}
+	if beforeBatchID <= 0 {
+		return
+	}
+
+	if err := c.ArchiveRawTable(ctx, flowJobName, beforeBatchID); err != nil {
+		c.logger.Error("failed to archive raw table", "flowName", flowJobName, "error", err)
+	}
+}
+
+// retentionCutoffBatchID returns the newest raw-table batch id all of whose
+// rows were synced before cutoff, i.e. the beforeBatchID ArchiveRawTable
+// should be called with to enforce the configured retention window.
+func (c *SnowflakeConnector) retentionCutoffBatchID(ctx context.Context, flowJobName string, cutoff time.Time) (int64, error) {
+	rawTableIdentifier := getRawTableIdentifier(flowJobName)
+
+	var batchID int64
+	if err := c.QueryRowContext(ctx, fmt.Sprintf(rawTableBatchMaxTimestampSQL, c.rawSchema, rawTableIdentifier,
+		cutoff.UnixNano())).Scan(&batchID); err != nil {
+		return 0, fmt.Errorf("failed to compute retention cutoff batch id: %w", err)
+	}
+	return batchID, nil
+}
+
+// ArchiveRawTable exports every not-yet-archived raw-table batch up to and
+// including beforeBatchID to the mirror's per-job stage (as Parquet, via a
+// direct Snowflake COPY INTO), deletes those rows from the raw table, and
+// checkpoints beforeBatchID as the last archived batch id. With the archive
+// destination set to ARCHIVE_DESTINATION_NONE the export is skipped and the
+// rows are only deleted; every other destination value exports to the same
+// stage. It's idempotent: calling it again with a beforeBatchID at or below
+// the last checkpoint is a no-op. archiveAfterNormalize is its only caller;
+// no RPC handler calls it.
+func (c *SnowflakeConnector) ArchiveRawTable(ctx context.Context, flowJobName string, beforeBatchID int64) error {
+	lastArchivedBatchID, err := c.GetLastArchivedBatchID(ctx, flowJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get last archived batch id: %w", err)
+	}
+	if beforeBatchID <= lastArchivedBatchID {
+		return nil
+	}
+
+	destination := protos.ArchiveDestination_ARCHIVE_DESTINATION_NONE
+	if c.config.Retention != nil {
+		destination = c.config.Retention.Destination
+	}
+
+	if destination != protos.ArchiveDestination_ARCHIVE_DESTINATION_NONE {
+		if err := c.exportRawBatchRangeToStage(ctx, flowJobName, lastArchivedBatchID, beforeBatchID, destination); err != nil {
+			return fmt.Errorf("failed to export raw table batches %d..%d to archive: %w",
+				lastArchivedBatchID+1, beforeBatchID, err)
+		}
+	}
+
+	rawTableIdentifier := getRawTableIdentifier(flowJobName)
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf(archiveRawTableRangeSQL,
+		c.rawSchema, rawTableIdentifier, lastArchivedBatchID, beforeBatchID)); err != nil {
+		return fmt.Errorf("failed to delete archived raw table batches %d..%d: %w",
+			lastArchivedBatchID+1, beforeBatchID, err)
+	}
+
+	if err := c.UpdateLastArchivedBatchID(ctx, flowJobName, beforeBatchID); err != nil {
+		return fmt.Errorf("failed to checkpoint last archived batch id: %w", err)
+	}
+
+	c.logger.Info("archived raw table batches",
+		"flowName", flowJobName, "fromBatchID", lastArchivedBatchID+1, "toBatchID", beforeBatchID,
+		"destination", destination.String())
+	return nil
+}
+
+// exportRawBatchRangeToStage stages every raw row in (afterBatchID,
+// beforeBatchID] as Parquet, under the mirror's existing per-job stage
+// (the same one CreateRawTable already provisions), rather than bringing
+// up a second upload path through the Avro sync handler, which is built
+// for streaming rows pulled from the source side and has no SQL-query
+// entry point. destination only gates whether this runs at all: the stage
+// itself is wherever the mirror's stage was configured to point (S3/GCS/
+// Azure) when it was created.
+func (c *SnowflakeConnector) exportRawBatchRangeToStage(
+	ctx context.Context,
+	flowJobName string,
+	afterBatchID int64,
+	beforeBatchID int64,
+	destination protos.ArchiveDestination,
+) error {
+	rawTableIdentifier := getRawTableIdentifier(flowJobName)
+	stage := c.getStageNameForJob(flowJobName)
+
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf(copyRawBatchRangeToStageSQL,
+		stage, flowJobName, afterBatchID, beforeBatchID, c.rawSchema, rawTableIdentifier, afterBatchID, beforeBatchID),
+	); err != nil {
+		return fmt.Errorf("failed to copy raw table batches %d..%d to stage %s: %w", afterBatchID+1, beforeBatchID, stage, err)
+	}
+	return nil
+}
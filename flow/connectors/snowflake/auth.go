@@ -0,0 +1,113 @@
+package connsnowflake
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/snowflakedb/gosnowflake"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// snowflakeAuthOptions carries the gosnowflake.Config fields that vary by
+// authentication method, so resolveSnowflakeAuth can build them once up
+// front and NewSnowflakeConnector stays agnostic to which one is in use.
+type snowflakeAuthOptions struct {
+	authenticator gosnowflake.AuthType
+	privateKey    *rsa.PrivateKey
+	password      string
+	token         string
+}
+
+func (o snowflakeAuthOptions) apply(cfg *gosnowflake.Config) {
+	cfg.Authenticator = o.authenticator
+	cfg.PrivateKey = o.privateKey
+	cfg.Password = o.password
+	cfg.Token = o.token
+}
+
+// resolveSnowflakeAuth maps config's Authenticator oneof onto the
+// gosnowflake.AuthType and credential fields it needs, covering key-pair
+// (JWT), username/password, client-credentials OAuth, and external browser
+// SSO. Configs predating the Authenticator oneof (PrivateKey/Password set
+// directly on SnowflakeConfig) keep working via the JWT default below.
+func resolveSnowflakeAuth(ctx context.Context, config *protos.SnowflakeConfig) (snowflakeAuthOptions, error) {
+	switch authn := config.Authenticator.(type) {
+	case *protos.SnowflakeConfig_JwtKey:
+		privateKeyRSA, err := shared.DecodePKCS8PrivateKey([]byte(authn.JwtKey.PrivateKey), authn.JwtKey.Password)
+		if err != nil {
+			return snowflakeAuthOptions{}, fmt.Errorf("failed to decode Snowflake JWT private key: %w", err)
+		}
+		return snowflakeAuthOptions{authenticator: gosnowflake.AuthTypeJwt, privateKey: privateKeyRSA}, nil
+	case *protos.SnowflakeConfig_UsernamePassword:
+		return snowflakeAuthOptions{
+			authenticator: gosnowflake.AuthTypeSnowflake,
+			password:      authn.UsernamePassword.Password,
+		}, nil
+	case *protos.SnowflakeConfig_Oauth:
+		token, err := fetchOAuthToken(ctx, authn.Oauth)
+		if err != nil {
+			return snowflakeAuthOptions{}, fmt.Errorf("failed to obtain Snowflake OAuth token: %w", err)
+		}
+		return snowflakeAuthOptions{authenticator: gosnowflake.AuthTypeOAuth, token: token}, nil
+	case *protos.SnowflakeConfig_ExternalBrowser:
+		return snowflakeAuthOptions{authenticator: gosnowflake.AuthTypeExternalBrowser}, nil
+	default:
+		privateKeyRSA, err := shared.DecodePKCS8PrivateKey([]byte(config.PrivateKey), config.Password)
+		if err != nil {
+			return snowflakeAuthOptions{}, err
+		}
+		return snowflakeAuthOptions{authenticator: gosnowflake.AuthTypeJwt, privateKey: privateKeyRSA}, nil
+	}
+}
+
+// oauthTokenResponse is the subset of a standard OAuth2 client-credentials
+// token response (RFC 6749 section 4.4.3) we care about.
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// fetchOAuthToken exchanges oauthConfig's client id/secret for an access
+// token at its token URL via the OAuth2 client-credentials grant, so
+// operators federating through Okta/Azure AD can authenticate without a
+// key pair or a long-lived password.
+func fetchOAuthToken(ctx context.Context, oauthConfig *protos.SnowflakeOAuthConfig) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if oauthConfig.Scope != "" {
+		form.Set("scope", oauthConfig.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauthConfig.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build oauth token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(oauthConfig.ClientId, oauthConfig.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp oauthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode oauth token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", errors.New("oauth token response did not contain an access_token")
+	}
+	return tokenResp.AccessToken, nil
+}
@@ -0,0 +1,292 @@
+package connsnowflake
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// RecreateOptions configures RecreateTable's shadow-table build: the pieces
+// of the target schema that can't be expressed as a plain column list
+// because they describe how to get existing data into the new shape.
+type RecreateOptions struct {
+	// ColumnMapping maps a target column name to the existing column it's
+	// populated from, for columns that were renamed rather than added.
+	// Target columns absent from ColumnMapping are assumed to share a name
+	// with their source column.
+	ColumnMapping map[string]string
+	// DefaultExprForAddedColumn gives the SQL expression (e.g. a literal or
+	// a SYSDATE()) used to populate a target column that has no
+	// corresponding existing column at all.
+	DefaultExprForAddedColumn map[string]string
+	// ClusterByExpr, if non-empty, is applied to the shadow table so the
+	// recreated table keeps the original's clustering key.
+	ClusterByExpr string
+	// Comment, if non-empty, is applied to the shadow table so the
+	// recreated table keeps the original's comment.
+	Comment string
+	// Env is forwarded to qvalue.ToDWHColumnType for target types that
+	// depend on session/flow settings.
+	Env map[string]string
+}
+
+// RecreateTable rebuilds existing to match newSchema by way of a shadow
+// table, for schema changes ALTER TABLE can't express in place — narrowing
+// a column's type, adding NOT NULL to a column that already has rows,
+// changing the clustering key, or reordering columns. None of those are
+// restricted on a table with no rows, so the shadow table starts as a
+// LIKE-copy of existing, has its columns added/renamed/retyped to match
+// newSchema while still empty, is backfilled from existing via an explicit
+// column mapping, and is swapped into existing's place atomically (the same
+// SWAP WITH cutover RenameTables uses for CUTOVER_STRATEGY_ATOMIC_SWAP)
+// before the old table is dropped. Callers are expected to have already
+// confirmed a plain ALTER TABLE won't do the job.
+func (c *SnowflakeConnector) RecreateTable(
+	ctx context.Context,
+	existing *utils.SchemaTable,
+	newSchema *protos.TableSchema,
+	opts RecreateOptions,
+) error {
+	existingNormalized := snowflakeSchemaTableNormalize(existing)
+	shadow := &utils.SchemaTable{Schema: existing.Schema, Table: existing.Table + "_PEERDB_RECREATE_SHADOW"}
+	shadowNormalized := snowflakeSchemaTableNormalize(shadow)
+
+	if _, err := c.execWithLogging(ctx,
+		fmt.Sprintf("CREATE OR REPLACE TABLE %s LIKE %s", shadowNormalized, existingNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to create shadow table %s: %w", shadowNormalized, err)
+	}
+
+	if err := c.recreateTableApplyOverrides(ctx, shadowNormalized, newSchema, opts); err != nil {
+		return err
+	}
+
+	targetColumns := make([]string, 0, len(newSchema.Columns))
+	selectExprs := make([]string, 0, len(newSchema.Columns))
+	for _, column := range newSchema.Columns {
+		targetColumns = append(targetColumns, SnowflakeIdentifierNormalize(column.Name))
+
+		if defaultExpr, ok := opts.DefaultExprForAddedColumn[column.Name]; ok {
+			selectExprs = append(selectExprs, defaultExpr)
+			continue
+		}
+
+		sourceName := column.Name
+		if mapped, ok := opts.ColumnMapping[column.Name]; ok {
+			sourceName = mapped
+		}
+		selectExprs = append(selectExprs, SnowflakeIdentifierNormalize(sourceName))
+	}
+
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM %s",
+		shadowNormalized, strings.Join(targetColumns, ","), strings.Join(selectExprs, ","), existingNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to backfill shadow table %s: %w", shadowNormalized, err)
+	}
+
+	if opts.ClusterByExpr != "" {
+		if _, err := c.execWithLogging(ctx,
+			fmt.Sprintf("ALTER TABLE %s CLUSTER BY (%s)", shadowNormalized, opts.ClusterByExpr),
+		); err != nil {
+			return fmt.Errorf("unable to reapply clustering key to shadow table %s: %w", shadowNormalized, err)
+		}
+	}
+	if opts.Comment != "" {
+		if _, err := c.execWithLogging(ctx,
+			fmt.Sprintf("COMMENT ON TABLE %s IS '%s'", shadowNormalized, strings.ReplaceAll(opts.Comment, "'", "''")),
+		); err != nil {
+			return fmt.Errorf("unable to reapply comment to shadow table %s: %w", shadowNormalized, err)
+		}
+	}
+
+	// SWAP WITH exchanges the two tables' underlying storage atomically, so
+	// existing's name never stops resolving to a table mid-cutover.
+	if _, err := c.execWithLogging(ctx,
+		fmt.Sprintf("ALTER TABLE %s SWAP WITH %s", existingNormalized, shadowNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to swap recreated table %s into place: %w", existingNormalized, err)
+	}
+	if _, err := c.execWithLogging(ctx, "DROP TABLE IF EXISTS "+shadowNormalized); err != nil {
+		return fmt.Errorf("unable to drop old table %s after recreate: %w", shadowNormalized, err)
+	}
+
+	return nil
+}
+
+// recreateTableApplyOverrides brings shadowNormalized — at this point a
+// LIKE-copy of the existing table — into newSchema's exact shape: columns
+// present only in newSchema are added, columns renamed via
+// opts.ColumnMapping are renamed, columns dropped from newSchema entirely
+// are dropped, and every surviving column's type is reapplied so narrowing
+// conversions that Snowflake refuses on a populated table go through on the
+// still-empty shadow table instead.
+func (c *SnowflakeConnector) recreateTableApplyOverrides(
+	ctx context.Context,
+	shadowNormalized string,
+	newSchema *protos.TableSchema,
+	opts RecreateOptions,
+) error {
+	rows, err := c.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT 0", shadowNormalized))
+	if err != nil {
+		return fmt.Errorf("unable to inspect shadow table %s columns: %w", shadowNormalized, err)
+	}
+	existingColumnNames, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("unable to read shadow table %s column names: %w", shadowNormalized, err)
+	}
+	existingColumns := make(map[string]bool, len(existingColumnNames))
+	for _, name := range existingColumnNames {
+		existingColumns[name] = true
+	}
+
+	usedSourceColumns := make(map[string]bool, len(newSchema.Columns))
+	for _, column := range newSchema.Columns {
+		sourceName := column.Name
+		if mapped, ok := opts.ColumnMapping[column.Name]; ok {
+			sourceName = mapped
+		}
+		usedSourceColumns[strings.ToUpper(sourceName)] = true
+
+		sfColType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(column.Type), opts.Env, protos.DBType_SNOWFLAKE, nil, column, newSchema.NullableEnabled,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to convert column type %s for %s: %w", column.Type, column.Name, err)
+		}
+
+		switch {
+		case !existingColumns[strings.ToUpper(sourceName)]:
+			// brand-new column: the shadow table has nothing to rename.
+			if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+				shadowNormalized, SnowflakeIdentifierNormalize(column.Name), sfColType)); err != nil {
+				return fmt.Errorf("unable to add column %s to shadow table %s: %w", column.Name, shadowNormalized, err)
+			}
+		case sourceName != column.Name:
+			if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+				shadowNormalized, SnowflakeIdentifierNormalize(sourceName), SnowflakeIdentifierNormalize(column.Name)),
+			); err != nil {
+				return fmt.Errorf("unable to rename column %s to %s on shadow table %s: %w",
+					sourceName, column.Name, shadowNormalized, err)
+			}
+			fallthrough
+		default:
+			if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DATA TYPE %s",
+				shadowNormalized, SnowflakeIdentifierNormalize(column.Name), sfColType),
+			); err != nil {
+				return fmt.Errorf("unable to retype column %s on shadow table %s: %w",
+					column.Name, shadowNormalized, err)
+			}
+		}
+	}
+
+	for _, name := range existingColumnNames {
+		if usedSourceColumns[strings.ToUpper(name)] {
+			continue
+		}
+		if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+			shadowNormalized, SnowflakeIdentifierNormalize(name))); err != nil {
+			return fmt.Errorf("unable to drop unused column %s from shadow table %s: %w", name, shadowNormalized, err)
+		}
+	}
+
+	slog.Debug("recreate: shadow table columns reconciled", "table", shadowNormalized)
+	return nil
+}
+
+// recreateTableAddColumns is ReplayTableSchemaDeltas' fallback for a table
+// whose ALTER TABLE ADD COLUMN failed outright — most commonly because the
+// table's clustering key or another property makes Snowflake refuse the
+// in-place change. Unlike the general RecreateTable, it doesn't need the
+// destination's full target schema: CREATE TABLE LIKE already reproduces
+// every existing column verbatim (type included), so only the added
+// columns need to be named here, and the backfill can rely on the two
+// tables sharing the same column order rather than an explicit mapping.
+func (c *SnowflakeConnector) recreateTableAddColumns(
+	ctx context.Context,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+) error {
+	dstTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+	if err != nil {
+		return fmt.Errorf("unable to parse destination table %s for recreate fallback: %w", schemaDelta.DstTableName, err)
+	}
+	dstNormalized := snowflakeSchemaTableNormalize(dstTable)
+	shadow := &utils.SchemaTable{Schema: dstTable.Schema, Table: dstTable.Table + "_PEERDB_RECREATE_SHADOW"}
+	shadowNormalized := snowflakeSchemaTableNormalize(shadow)
+
+	// This fallback runs after replayAddedColumns has already failed partway
+	// through schemaDelta.AddedColumns, and Snowflake auto-commits DDL even
+	// inside an open transaction — so dst may already carry some of these
+	// columns from ALTERs that succeeded before the one that failed. Inspect
+	// dst's actual current columns so CREATE TABLE LIKE's copy of them isn't
+	// double-counted below.
+	dstRows, err := c.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT 0", dstNormalized))
+	if err != nil {
+		return fmt.Errorf("unable to inspect destination table %s columns: %w", dstNormalized, err)
+	}
+	dstColumnNames, err := dstRows.Columns()
+	dstRows.Close()
+	if err != nil {
+		return fmt.Errorf("unable to read destination table %s column names: %w", dstNormalized, err)
+	}
+	dstColumns := make(map[string]bool, len(dstColumnNames))
+	for _, name := range dstColumnNames {
+		dstColumns[strings.ToUpper(name)] = true
+	}
+
+	if _, err := c.execWithLogging(ctx,
+		fmt.Sprintf("CREATE OR REPLACE TABLE %s LIKE %s", shadowNormalized, dstNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to create shadow table %s: %w", shadowNormalized, err)
+	}
+
+	addedExprs := make([]string, 0, len(schemaDelta.AddedColumns))
+	for _, addedColumn := range schemaDelta.AddedColumns {
+		if dstColumns[strings.ToUpper(addedColumn.Name)] {
+			// already present on dst (and thus already copied onto shadow by
+			// CREATE TABLE LIKE) from an ALTER that succeeded before the one
+			// that triggered this fallback — adding it again would either
+			// no-op or duplicate it in the backfill below.
+			continue
+		}
+		sfColType, err := qvalue.ToDWHColumnType(
+			ctx, types.QValueKind(addedColumn.Type), env, protos.DBType_SNOWFLAKE, nil, addedColumn, schemaDelta.NullableEnabled,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to convert column type %s for %s: %w", addedColumn.Type, addedColumn.Name, err)
+		}
+		if _, err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
+			shadowNormalized, SnowflakeIdentifierNormalize(addedColumn.Name), sfColType),
+		); err != nil {
+			return fmt.Errorf("unable to add column %s to shadow table %s: %w", addedColumn.Name, shadowNormalized, err)
+		}
+		addedExprs = append(addedExprs, "NULL")
+	}
+
+	// shadow's columns are dst's original columns, in order, followed by the
+	// added columns in the order they were just ALTERed in — SELECT * from
+	// dst lines up with that positionally, so no named column list is needed.
+	if _, err := c.execWithLogging(ctx, fmt.Sprintf("INSERT INTO %s SELECT *,%s FROM %s",
+		shadowNormalized, strings.Join(addedExprs, ","), dstNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to backfill shadow table %s: %w", shadowNormalized, err)
+	}
+
+	if _, err := c.execWithLogging(ctx,
+		fmt.Sprintf("ALTER TABLE %s SWAP WITH %s", dstNormalized, shadowNormalized),
+	); err != nil {
+		return fmt.Errorf("unable to swap recreated table %s into place: %w", dstNormalized, err)
+	}
+	if _, err := c.execWithLogging(ctx, "DROP TABLE IF EXISTS "+shadowNormalized); err != nil {
+		return fmt.Errorf("unable to drop old table %s after recreate: %w", shadowNormalized, err)
+	}
+
+	return nil
+}
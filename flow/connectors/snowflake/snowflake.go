@@ -18,6 +18,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/sqlmw"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
@@ -28,6 +29,11 @@ import (
 )
 
 const (
+	// sqlmwSlowQueryThreshold and sqlmwMaxAttempts configure the sqlmw
+	// middleware every query goes through: see newSQLMW below.
+	sqlmwSlowQueryThreshold = 30 * time.Second
+	sqlmwMaxAttempts        = 3
+
 	rawTablePrefix    = "_PEERDB_RAW"
 	createSchemaSQL   = "CREATE TRANSIENT SCHEMA IF NOT EXISTS %s"
 	createRawTableSQL = `CREATE TABLE IF NOT EXISTS %s.%s(_PEERDB_UID STRING NOT NULL,
@@ -61,7 +67,17 @@ const (
 	 ARRAY_AGG(DISTINCT _PEERDB_UNCHANGED_TOAST_COLUMNS) FROM %s.%s WHERE
 	 _PEERDB_BATCH_ID = %d AND _PEERDB_RECORD_TYPE != 2
 	 GROUP BY _PEERDB_DESTINATION_TABLE_NAME`
-	getTableSchemaSQL = `SELECT COLUMN_NAME, DATA_TYPE, NUMERIC_PRECISION, NUMERIC_SCALE FROM INFORMATION_SCHEMA.COLUMNS
+	// appendRecordsSQL backs the APPEND SyncMode: instead of the MERGE path's
+	// dedup-by-primary-key, every raw row for the batch is inserted into the
+	// destination as-is, tagged with its operation type and commit time, so
+	// event-stream-style mirrors can skip dedup/merge cost entirely.
+	appendRecordsSQL = `INSERT INTO %s (%s)
+		SELECT %s,_PEERDB_RECORD_TYPE,TO_TIMESTAMP_NTZ(_PEERDB_TIMESTAMP,9),_PEERDB_BATCH_ID
+		FROM %s.%s WHERE _PEERDB_BATCH_ID = %d AND _PEERDB_DESTINATION_TABLE_NAME = ?`
+	appendOperationColName = "_PEERDB_OPERATION"
+	appendTimestampColName = "_PEERDB_TIMESTAMP"
+	appendBatchIdColName   = "_PEERDB_BATCH_ID"
+	getTableSchemaSQL      = `SELECT COLUMN_NAME, DATA_TYPE, NUMERIC_PRECISION, NUMERIC_SCALE FROM INFORMATION_SCHEMA.COLUMNS
 	 WHERE UPPER(TABLE_SCHEMA)=? AND UPPER(TABLE_NAME)=? ORDER BY ORDINAL_POSITION`
 
 	checkIfTableExistsSQL = `SELECT TO_BOOLEAN(COUNT(1)) FROM INFORMATION_SCHEMA.TABLES
@@ -71,10 +87,25 @@ const (
 
 type SnowflakeConnector struct {
 	*metadataStore.PostgresMetadata
-	*sql.DB
+	*sqlmw.DB
 	logger    log.Logger
 	config    *protos.SnowflakeConfig
 	rawSchema string
+	tunnel    *sshTunnel
+}
+
+// newSQLMW wraps database with the middleware every Snowflake query goes
+// through: retry-on-transient-error (session expiry, network resets),
+// per-query-kind metrics (see sqlmw.QueryKind), and slow-query logging.
+// This is what lets mergeTablesForBatch, CreateRawTable, SyncFlowCleanup,
+// and everything else that calls c.ExecContext/QueryContext/QueryRowContext
+// get all three without each call site doing its own retry/timing/logging.
+func newSQLMW(database *sql.DB, logger log.Logger) *sqlmw.DB {
+	return sqlmw.Wrap(database, sqlmw.Options{
+		Logger:             logger,
+		SlowQueryThreshold: sqlmwSlowQueryThreshold,
+		MaxAttempts:        sqlmwMaxAttempts,
+	})
 }
 
 func NewSnowflakeConnector(
@@ -82,20 +113,25 @@ func NewSnowflakeConnector(
 	snowflakeProtoConfig *protos.SnowflakeConfig,
 ) (*SnowflakeConnector, error) {
 	logger := internal.LoggerFromCtx(ctx)
-	PrivateKeyRSA, err := shared.DecodePKCS8PrivateKey([]byte(snowflakeProtoConfig.PrivateKey),
-		snowflakeProtoConfig.Password)
+	authOptions, err := resolveSnowflakeAuth(ctx, snowflakeProtoConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	var tunnel *sshTunnel
+	if snowflakeProtoConfig.SshConfig != nil {
+		tunnel, err = newSSHTunnel(ctx, snowflakeProtoConfig.SshConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up SSH tunnel for Snowflake peer: %w", err)
+		}
+	}
+
 	additionalParams := make(map[string]*string)
 	additionalParams["CLIENT_SESSION_KEEP_ALIVE"] = ptr.String("true")
 
 	snowflakeConfig := gosnowflake.Config{
 		Account:          snowflakeProtoConfig.AccountId,
 		User:             snowflakeProtoConfig.Username,
-		Authenticator:    gosnowflake.AuthTypeJwt,
-		PrivateKey:       PrivateKeyRSA,
 		Database:         snowflakeProtoConfig.Database,
 		Warehouse:        snowflakeProtoConfig.Warehouse,
 		Role:             snowflakeProtoConfig.Role,
@@ -103,19 +139,32 @@ func NewSnowflakeConnector(
 		DisableTelemetry: true,
 		Params:           additionalParams,
 	}
+	authOptions.apply(&snowflakeConfig)
+	if tunnel != nil {
+		snowflakeConfig.Transporter = tunnel.transporter()
+	}
 
 	snowflakeConfigDSN, err := gosnowflake.DSN(&snowflakeConfig)
 	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
 		return nil, fmt.Errorf("failed to get DSN from Snowflake config: %w", err)
 	}
 
 	database, err := sql.Open("snowflake", snowflakeConfigDSN)
 	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
 		return nil, fmt.Errorf("failed to open connection to Snowflake peer: %w", err)
 	}
 
 	// checking if connection was actually established, since sql.Open doesn't guarantee that
 	if err := database.PingContext(ctx); err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
 		return nil, fmt.Errorf("failed to open connection to Snowflake peer: %w", err)
 	}
 
@@ -126,15 +175,19 @@ func NewSnowflakeConnector(
 
 	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
 	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
 		return nil, fmt.Errorf("could not connect to metadata store: %w", err)
 	}
 
 	return &SnowflakeConnector{
 		PostgresMetadata: pgMetadata,
-		DB:               database,
+		DB:               newSQLMW(database, logger),
 		rawSchema:        rawSchema,
 		logger:           logger,
 		config:           snowflakeProtoConfig,
+		tunnel:           tunnel,
 	}, nil
 }
 
@@ -158,6 +211,14 @@ type UnchangedToastColumnResult struct {
 }
 
 func (c *SnowflakeConnector) ValidateCheck(ctx context.Context) error {
+	// probe the tunnel, if any, first, so a dropped bastion connection is
+	// reported distinctly from a Snowflake auth/permissions failure below
+	if c.tunnel != nil {
+		if err := c.tunnel.checkAlive(); err != nil {
+			return fmt.Errorf("SSH tunnel to Snowflake bastion is unreachable: %w", err)
+		}
+	}
+
 	// check if schema exists
 	schemaExists, err := c.checkIfRawSchemaExists(ctx)
 	if err != nil {
@@ -212,7 +273,12 @@ func (c *SnowflakeConnector) ValidateCheck(ctx context.Context) error {
 
 func (c *SnowflakeConnector) Close() error {
 	if c != nil {
-		return c.DB.Close()
+		dbErr := c.DB.Close()
+		var tunnelErr error
+		if c.tunnel != nil {
+			tunnelErr = c.tunnel.Close()
+		}
+		return errors.Join(dbErr, tunnelErr)
 	}
 	return nil
 }
@@ -222,6 +288,30 @@ func (c *SnowflakeConnector) ConnectionActive(ctx context.Context) error {
 	return c.PingContext(ctx)
 }
 
+// HealthCheck reports reachability, round-trip latency, and the Snowflake
+// release running on the warehouse, for connectors.HealthMonitor to poll on
+// a schedule. Snowflake has no replication slot or lag concept of its own
+// (it's always a destination here), so ReplicationLagBytes and FreeSlots are
+// left at their zero value.
+func (c *SnowflakeConnector) HealthCheck(ctx context.Context) (shared.HealthStatus, error) {
+	start := time.Now()
+	if err := c.PingContext(ctx); err != nil {
+		return shared.HealthStatus{}, fmt.Errorf("failed to reach Snowflake peer: %w", err)
+	}
+	latency := time.Since(start)
+
+	var version string
+	if err := c.QueryRowContext(ctx, "SELECT CURRENT_VERSION()").Scan(&version); err != nil {
+		return shared.HealthStatus{}, fmt.Errorf("failed to query Snowflake version: %w", err)
+	}
+
+	return shared.HealthStatus{
+		Up:      true,
+		Latency: latency,
+		Version: version,
+	}, nil
+}
+
 func (c *SnowflakeConnector) getDistinctTableNamesInBatch(
 	ctx context.Context,
 	flowJobName string,
@@ -360,27 +450,13 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 			continue
 		}
 
-		for _, addedColumn := range schemaDelta.AddedColumns {
-			qvKind := types.QValueKind(addedColumn.Type)
-			sfColtype, err := qvalue.ToDWHColumnType(
-				ctx, qvKind, env, protos.DBType_SNOWFLAKE, nil, addedColumn, schemaDelta.NullableEnabled,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to convert column type %s to snowflake type: %w",
-					addedColumn.Type, err)
-			}
-
-			if _, err := tableSchemaModifyTx.ExecContext(ctx,
-				fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS \"%s\" %s",
-					schemaDelta.DstTableName, strings.ToUpper(addedColumn.Name), sfColtype),
-			); err != nil {
-				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
-					schemaDelta.DstTableName, err)
+		if err := c.replayAddedColumns(ctx, tableSchemaModifyTx, env, schemaDelta); err != nil {
+			c.logger.Warn("in-place ALTER TABLE ADD COLUMN failed, falling back to recreate-and-copy",
+				"destination table name", schemaDelta.DstTableName, "error", err)
+			if fallbackErr := c.recreateTableAddColumns(ctx, env, schemaDelta); fallbackErr != nil {
+				return fmt.Errorf("recreate-and-copy fallback failed for table %s: %w",
+					schemaDelta.DstTableName, fallbackErr)
 			}
-			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name,
-				sfColtype),
-				"destination table name", schemaDelta.DstTableName,
-				"source table name", schemaDelta.SrcTableName)
 		}
 	}
 
@@ -392,6 +468,40 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	return nil
 }
 
+// replayAddedColumns runs schemaDelta's added columns through ALTER TABLE
+// ADD COLUMN, the common case that doesn't need recreate-and-copy. Callers
+// fall back to recreateTableAddColumns when this returns an error.
+func (c *SnowflakeConnector) replayAddedColumns(
+	ctx context.Context,
+	tx *sql.Tx,
+	env map[string]string,
+	schemaDelta *protos.TableSchemaDelta,
+) error {
+	for _, addedColumn := range schemaDelta.AddedColumns {
+		qvKind := types.QValueKind(addedColumn.Type)
+		sfColtype, err := qvalue.ToDWHColumnType(
+			ctx, qvKind, env, protos.DBType_SNOWFLAKE, nil, addedColumn, schemaDelta.NullableEnabled,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to convert column type %s to snowflake type: %w",
+				addedColumn.Type, err)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS \"%s\" %s",
+				schemaDelta.DstTableName, strings.ToUpper(addedColumn.Name), sfColtype),
+		); err != nil {
+			return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
+				schemaDelta.DstTableName, err)
+		}
+		c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name,
+			sfColtype),
+			"destination table name", schemaDelta.DstTableName,
+			"source table name", schemaDelta.SrcTableName)
+	}
+	return nil
+}
+
 func (c *SnowflakeConnector) withMirrorNameQueryTag(ctx context.Context, mirrorName string) context.Context {
 	return gosnowflake.WithQueryTag(ctx, "peerdb-mirror-"+mirrorName)
 }
@@ -479,15 +589,21 @@ func (c *SnowflakeConnector) NormalizeRecords(ctx context.Context, req *model.No
 
 	for batchId := normBatchID + 1; batchId <= req.SyncBatchID; batchId++ {
 		c.logger.Info(fmt.Sprintf("normalizing records for batch %d [of %d]", batchId, req.SyncBatchID))
-		mergeErr := c.mergeTablesForBatch(ctx, batchId,
-			req.FlowJobName, req.Env, req.TableNameSchemaMapping,
-			&protos.PeerDBColumns{
-				SoftDeleteColName: req.SoftDeleteColName,
-				SyncedAtColName:   req.SyncedAtColName,
-			},
-		)
-		if mergeErr != nil {
-			return model.NormalizeResponse{}, mergeErr
+
+		var normalizeErr error
+		if req.SyncMode == protos.SyncMode_SYNC_MODE_APPEND {
+			normalizeErr = c.appendRecordsForBatch(ctx, batchId, req.FlowJobName, req.TableNameSchemaMapping)
+		} else {
+			normalizeErr = c.mergeTablesForBatch(ctx, batchId,
+				req.FlowJobName, req.Env, req.TableNameSchemaMapping,
+				&protos.PeerDBColumns{
+					SoftDeleteColName: req.SoftDeleteColName,
+					SyncedAtColName:   req.SyncedAtColName,
+				},
+			)
+		}
+		if normalizeErr != nil {
+			return model.NormalizeResponse{}, normalizeErr
 		}
 
 		if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, batchId); err != nil {
@@ -495,6 +611,8 @@ func (c *SnowflakeConnector) NormalizeRecords(ctx context.Context, req *model.No
 		}
 	}
 
+	c.archiveAfterNormalize(ctx, req.FlowJobName)
+
 	return model.NormalizeResponse{
 		StartBatchID: normBatchID + 1,
 		EndBatchID:   req.SyncBatchID,
@@ -579,6 +697,59 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 	return nil
 }
 
+// appendRecordsForBatch implements the APPEND SyncMode: it bypasses
+// mergeTablesForBatch's dedup-by-primary-key MERGE entirely and instead
+// INSERTs every raw row for batchId straight into its destination table,
+// tagging each with its operation type, commit timestamp, and batch id, for
+// event-stream-style mirrors that don't need (and don't want to pay for)
+// dedup.
+func (c *SnowflakeConnector) appendRecordsForBatch(
+	ctx context.Context,
+	batchId int64,
+	flowName string,
+	tableToSchema map[string]*protos.TableSchema,
+) error {
+	destinationTableNames, err := c.getDistinctTableNamesInBatch(ctx, flowName, batchId, tableToSchema)
+	if err != nil {
+		return err
+	}
+
+	rawTableIdentifier := getRawTableIdentifier(flowName)
+
+	for _, tableName := range destinationTableNames {
+		tableSchema := tableToSchema[tableName]
+
+		columnNames := make([]string, 0, len(tableSchema.Columns)+3)
+		flattenedCols := make([]string, 0, len(tableSchema.Columns))
+		for _, column := range tableSchema.Columns {
+			normalizedColName := SnowflakeIdentifierNormalize(column.Name)
+			columnNames = append(columnNames, normalizedColName)
+			flattenedCols = append(flattenedCols,
+				fmt.Sprintf("TO_VARIANT(PARSE_JSON(_PEERDB_DATA)):\"%s\"", column.Name))
+		}
+		columnNames = append(columnNames, appendOperationColName, appendTimestampColName, appendBatchIdColName)
+
+		insertStatement := fmt.Sprintf(appendRecordsSQL,
+			tableName,
+			strings.Join(columnNames, ","),
+			strings.Join(flattenedCols, ","),
+			c.rawSchema, rawTableIdentifier, batchId)
+
+		startTime := time.Now()
+		c.logger.Info("[append] appending records...", "destTable", tableName, "batchId", batchId)
+
+		if _, err := c.ExecContext(ctx, insertStatement, tableName); err != nil {
+			return fmt.Errorf("failed to append records into %s (statement: %s): %w",
+				tableName, insertStatement, err)
+		}
+
+		c.logger.Info(fmt.Sprintf("[append] appended records into %s, took: %d seconds",
+			tableName, time.Since(startTime)/time.Second), "batchId", batchId)
+	}
+
+	return nil
+}
+
 func (c *SnowflakeConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
 	ctx = c.withMirrorNameQueryTag(ctx, req.FlowJobName)
 
@@ -690,8 +861,13 @@ func generateCreateTableSQLForNormalizedTable(
 		createTableSQLArray = append(createTableSQLArray, config.SyncedAtColName+" TIMESTAMP DEFAULT SYSDATE()")
 	}
 
-	// add composite primary key to the table
-	if len(tableSchema.PrimaryKeyColumns) > 0 && !tableSchema.IsReplicaIdentityFull {
+	if config.SyncMode == protos.SyncMode_SYNC_MODE_APPEND {
+		// append mode keeps every row instead of deduping by primary key, so
+		// surface the operation type and batch id instead of a PRIMARY KEY
+		createTableSQLArray = append(createTableSQLArray,
+			appendOperationColName+" INTEGER", appendTimestampColName+" TIMESTAMP_NTZ", appendBatchIdColName+" INT")
+	} else if len(tableSchema.PrimaryKeyColumns) > 0 && !tableSchema.IsReplicaIdentityFull {
+		// add composite primary key to the table
 		normalizedPrimaryKeyCols := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
 		for _, primaryKeyCol := range tableSchema.PrimaryKeyColumns {
 			normalizedPrimaryKeyCols = append(normalizedPrimaryKeyCols,
@@ -719,6 +895,14 @@ func (c *SnowflakeConnector) RenameTables(
 	req *protos.RenameTablesInput,
 	tableNameSchemaMapping map[string]*protos.TableSchema,
 ) (*protos.RenameTablesOutput, error) {
+	if err := c.validateCrossDatabaseRename(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if req.AtomicRename {
+		return c.renameTablesAtomic(ctx, req, tableNameSchemaMapping)
+	}
+
 	renameTablesTx, err := c.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("unable to begin transaction for rename tables: %w", err)
@@ -731,40 +915,24 @@ func (c *SnowflakeConnector) RenameTables(
 	}()
 
 	for _, renameRequest := range req.RenameTableOptions {
-		srcTable, err := utils.ParseSchemaTable(renameRequest.CurrentName)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse source %s: %w", renameRequest.CurrentName, err)
-		}
-
-		resyncTableExists, err := c.checkIfTableExists(
-			ctx,
-			SnowflakeQuotelessIdentifierNormalize(srcTable.Schema),
-			SnowflakeQuotelessIdentifierNormalize(srcTable.Table),
-		)
+		srcEndpoint, err := c.resolveRenameEndpoint(ctx, renameRequest.CurrentName)
 		if err != nil {
-			return nil, fmt.Errorf("unable to check if table %s exists: %w", srcTable, err)
+			return nil, err
 		}
 
-		if !resyncTableExists {
-			c.logger.Info(fmt.Sprintf("_resync table '%s' does not exist, skipping rename", srcTable))
+		if !srcEndpoint.exists {
+			c.logger.Info(fmt.Sprintf("_resync table '%s' does not exist, skipping rename", renameRequest.CurrentName))
 			continue
 		}
 
-		dstTable, err := utils.ParseSchemaTable(renameRequest.NewName)
+		dstEndpoint, err := c.resolveRenameEndpoint(ctx, renameRequest.NewName)
 		if err != nil {
-			return nil, fmt.Errorf("unable to parse destination %s: %w", renameRequest.NewName, err)
+			return nil, err
 		}
 
-		src := snowflakeSchemaTableNormalize(srcTable)
-		dst := snowflakeSchemaTableNormalize(dstTable)
-
-		originalTableExists, err := c.checkIfTableExists(ctx,
-			SnowflakeQuotelessIdentifierNormalize(dstTable.Schema),
-			SnowflakeQuotelessIdentifierNormalize(dstTable.Table),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("unable to check if original table %s exists: %w", dstTable, err)
-		}
+		src := srcEndpoint.normalized
+		dst := dstEndpoint.normalized
+		originalTableExists := dstEndpoint.exists
 
 		if originalTableExists {
 			if req.SoftDeleteColName != "" {
@@ -799,16 +967,31 @@ func (c *SnowflakeConnector) RenameTables(
 		// renaming and dropping such that the _resync table is the new destination
 		c.logger.Info(fmt.Sprintf("renaming table '%s' to '%s'...", src, dst))
 
-		// drop the dst table if exists
-		_, err = c.execWithLoggingTx(ctx, "DROP TABLE IF EXISTS "+dst, renameTablesTx)
-		if err != nil {
-			return nil, fmt.Errorf("unable to drop table %s: %w", dst, err)
-		}
+		if originalTableExists && req.CutoverStrategy == protos.CutoverStrategy_CUTOVER_STRATEGY_ATOMIC_SWAP {
+			// SWAP WITH atomically exchanges the two tables' underlying storage,
+			// so dst's name never stops resolving to a table mid-cutover, unlike
+			// DROP+RENAME below, which has a gap where dst doesn't exist at all.
+			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf("ALTER TABLE %s SWAP WITH %s", dst, src), renameTablesTx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to swap table %s with %s: %w", dst, src, err)
+			}
 
-		// rename the src table to dst
-		_, err = c.execWithLoggingTx(ctx, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", src, dst), renameTablesTx)
-		if err != nil {
-			return nil, fmt.Errorf("unable to rename table %s to %s: %w", src, dst, err)
+			_, err = c.execWithLoggingTx(ctx, "DROP TABLE IF EXISTS "+src, renameTablesTx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to drop table %s after swap: %w", src, err)
+			}
+		} else {
+			// dst doesn't exist yet (nothing to swap with), or the atomic swap
+			// wasn't requested: fall back to the original drop-then-rename.
+			_, err = c.execWithLoggingTx(ctx, "DROP TABLE IF EXISTS "+dst, renameTablesTx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to drop table %s: %w", dst, err)
+			}
+
+			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", src, dst), renameTablesTx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to rename table %s to %s: %w", src, dst, err)
+			}
 		}
 
 		c.logger.Info(fmt.Sprintf("successfully renamed table '%s' to '%s'", src, dst))
@@ -823,9 +1006,106 @@ func (c *SnowflakeConnector) RenameTables(
 	}, nil
 }
 
+// renameTablesAtomic is RenameTables' req.AtomicRename path: rather than
+// each DROP/SWAP/RENAME auto-committing independently as its own
+// ExecContext call inside an otherwise toothless Go-side transaction (DDL
+// isn't transactional in Snowflake), every table's statements are joined
+// into one script and submitted as a single multi-statement request via
+// MULTI_STATEMENT_COUNT. gosnowflake aborts a multi-statement request at
+// its first failing statement, so a failure partway through stops there
+// instead of continuing on to rename the rest of the batch — a mid-batch
+// failure can no longer leave later tables renamed while earlier ones
+// silently failed.
+func (c *SnowflakeConnector) renameTablesAtomic(
+	ctx context.Context,
+	req *protos.RenameTablesInput,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) (*protos.RenameTablesOutput, error) {
+	var script strings.Builder
+	numStatements := 0
+	writeStatement := func(stmt string) {
+		if numStatements > 0 {
+			script.WriteString(";\n")
+		}
+		script.WriteString(stmt)
+		numStatements++
+	}
+
+	for _, renameRequest := range req.RenameTableOptions {
+		srcEndpoint, err := c.resolveRenameEndpoint(ctx, renameRequest.CurrentName)
+		if err != nil {
+			return nil, err
+		}
+		if !srcEndpoint.exists {
+			c.logger.Info(fmt.Sprintf("_resync table '%s' does not exist, skipping rename", renameRequest.CurrentName))
+			continue
+		}
+
+		dstEndpoint, err := c.resolveRenameEndpoint(ctx, renameRequest.NewName)
+		if err != nil {
+			return nil, err
+		}
+
+		src := srcEndpoint.normalized
+		dst := dstEndpoint.normalized
+		originalTableExists := dstEndpoint.exists
+
+		if originalTableExists && req.SoftDeleteColName != "" {
+			tableSchema := tableNameSchemaMapping[renameRequest.CurrentName]
+			columnNames := make([]string, 0, len(tableSchema.Columns))
+			for _, col := range tableSchema.Columns {
+				columnNames = append(columnNames, SnowflakeIdentifierNormalize(col.Name))
+			}
+			pkeyColumnNames := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+			for _, col := range tableSchema.PrimaryKeyColumns {
+				pkeyColumnNames = append(pkeyColumnNames, SnowflakeIdentifierNormalize(col))
+			}
+			allCols := strings.Join(columnNames, ",")
+			pkeyCols := strings.Join(pkeyColumnNames, ",")
+
+			writeStatement(fmt.Sprintf("INSERT INTO %s(%s) SELECT %s,true AS %s FROM %s WHERE (%s) NOT IN (SELECT %s FROM %s)",
+				src, fmt.Sprintf("%s,%s", allCols, req.SoftDeleteColName), allCols, req.SoftDeleteColName,
+				dst, pkeyCols, pkeyCols, src))
+		}
+
+		if originalTableExists && req.CutoverStrategy == protos.CutoverStrategy_CUTOVER_STRATEGY_ATOMIC_SWAP {
+			writeStatement(fmt.Sprintf("ALTER TABLE %s SWAP WITH %s", dst, src))
+			writeStatement("DROP TABLE IF EXISTS " + src)
+		} else {
+			writeStatement("DROP TABLE IF EXISTS " + dst)
+			writeStatement(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", src, dst))
+		}
+
+		c.logger.Info(fmt.Sprintf("queued atomic rename of table '%s' to '%s'", src, dst))
+	}
+
+	if numStatements == 0 {
+		return &protos.RenameTablesOutput{FlowJobName: req.FlowJobName}, nil
+	}
+
+	// 0 tells the driver the statement count varies, rather than requiring an
+	// exact match against a count we'd otherwise have to precompute twice.
+	multiStatementCtx := gosnowflake.WithMultiStatement(ctx, 0)
+	if _, err := c.ExecContext(multiStatementCtx, script.String()); err != nil {
+		return nil, fmt.Errorf("atomic multi-table rename aborted, nothing past the failing statement ran: %w", err)
+	}
+
+	return &protos.RenameTablesOutput{
+		FlowJobName: req.FlowJobName,
+	}, nil
+}
+
 func (c *SnowflakeConnector) CreateTablesFromExisting(ctx context.Context, req *protos.CreateTablesFromExistingInput) (
 	*protos.CreateTablesFromExistingOutput, error,
 ) {
+	identifiers := make([]string, 0, len(req.NewToExistingTableMapping)*2)
+	for newTable, existingTable := range req.NewToExistingTableMapping {
+		identifiers = append(identifiers, newTable, existingTable)
+	}
+	if err := c.validateCrossDatabaseIdentifiers(ctx, identifiers); err != nil {
+		return nil, err
+	}
+
 	createTablesFromExistingTx, err := c.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("unable to begin transaction for rename tables: %w", err)
@@ -859,17 +1139,60 @@ func (c *SnowflakeConnector) CreateTablesFromExisting(ctx context.Context, req *
 	}, nil
 }
 
+// removeTableEntriesBatchSize bounds a single DELETE issued by
+// RemoveTableEntriesFromRawTable, so purging a large backlog of raw rows
+// can't run long enough to hit Snowflake's statement timeout.
+const removeTableEntriesBatchSize = 100_000
+
+// removeTableEntriesBatchSQL deletes at most a batch of rows matching
+// tableName/the batch id range, identified by _PEERDB_UID since the raw
+// table has no other key unique enough to address individual rows. This
+// CTE-and-QUALIFY shape stands in for DELETE ... LIMIT, which Snowflake
+// doesn't support.
+const removeTableEntriesBatchSQL = `DELETE FROM %s.%s WHERE _PEERDB_UID IN (
+	SELECT _PEERDB_UID FROM %s.%s
+	WHERE _PEERDB_DESTINATION_TABLE_NAME = ? AND _PEERDB_BATCH_ID > ? AND _PEERDB_BATCH_ID <= ?
+	QUALIFY ROW_NUMBER() OVER (ORDER BY _PEERDB_BATCH_ID) <= ?
+)`
+
 func (c *SnowflakeConnector) RemoveTableEntriesFromRawTable(
 	ctx context.Context,
 	req *protos.RemoveTablesFromRawTableInput,
 ) error {
 	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
 	for _, tableName := range req.DestinationTableNames {
-		_, err := c.execWithLogging(ctx, fmt.Sprintf("DELETE FROM %s.%s WHERE _PEERDB_DESTINATION_TABLE_NAME = '%s'"+
-			" AND _PEERDB_BATCH_ID > %d AND _PEERDB_BATCH_ID <= %d",
-			c.rawSchema, rawTableIdentifier, tableName, req.NormalizeBatchId, req.SyncBatchId))
-		if err != nil {
-			c.logger.Error("failed to remove entries from raw table", "error", err)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("context canceled while removing entries from raw table, resume from table '%s' on the "+
+				"next normalize cycle: %w", tableName, err)
+		}
+
+		deleteBatchQuery := fmt.Sprintf(removeTableEntriesBatchSQL,
+			c.rawSchema, rawTableIdentifier, c.rawSchema, rawTableIdentifier)
+
+		var totalRemoved int64
+		for {
+			result, err := c.ExecContext(ctx, deleteBatchQuery,
+				tableName, req.NormalizeBatchId, req.SyncBatchId, removeTableEntriesBatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to remove entries for table '%s' from raw table after removing %d rows: %w",
+					tableName, totalRemoved, err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to read rows affected while removing entries for table '%s': %w", tableName, err)
+			}
+			totalRemoved += rowsAffected
+			c.logger.Info("removed batch of entries from raw table",
+				"table", tableName, "batchRowsRemoved", rowsAffected, "totalRowsRemoved", totalRemoved)
+
+			if rowsAffected < removeTableEntriesBatchSize {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("context canceled after removing %d rows for table '%s', resume on the next "+
+					"normalize cycle: %w", totalRemoved, tableName, err)
+			}
 		}
 
 		c.logger.Info(fmt.Sprintf("successfully removed entries for table '%s' from raw table", tableName))
@@ -0,0 +1,51 @@
+package sqlmw
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/snowflakedb/gosnowflake"
+)
+
+// transientSnowflakeErrorCodes are gosnowflake.SnowflakeError.Number values
+// worth retrying: 000629 is a session token expiring mid-query, and 390114
+// is a session gone idle/expired — both clear up on a fresh attempt rather
+// than indicating anything wrong with the query itself.
+var transientSnowflakeErrorCodes = map[int]bool{
+	629:    true,
+	390114: true,
+}
+
+// IsTransientSnowflakeError reports whether err is a Snowflake session
+// expiry or a network-level failure (connection reset or refused, broken
+// pipe, I/O timeout, EOF), any of which a retried attempt is likely to
+// succeed past. It's the default Options.IsTransient.
+func IsTransientSnowflakeError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sfErr *gosnowflake.SnowflakeError
+	if errors.As(err, &sfErr) && transientSnowflakeErrorCodes[sfErr.Number] {
+		return true
+	}
+
+	// Fall back to substring matching for errors gosnowflake doesn't surface
+	// as a typed SnowflakeError (e.g. a raw net.Error from a dropped
+	// connection), and for the error codes themselves in case they appear
+	// wrapped inside another error type's message.
+	msg := err.Error()
+	for _, needle := range []string{
+		"000629", "390114",
+		"connection reset",
+		"broken pipe",
+		"connection refused",
+		"i/o timeout",
+		"EOF",
+	} {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
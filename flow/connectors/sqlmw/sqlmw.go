@@ -0,0 +1,197 @@
+// Package sqlmw wraps a *sql.DB with a small middleware chain — retry on
+// transient errors, per-query-kind metrics, and slow-query logging — so a
+// connector gets all three for every call without threading them through
+// each ad-hoc ExecContext/QueryContext site by hand.
+package sqlmw
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/log"
+)
+
+// QueryKind buckets a query by its leading keyword (or, for SELECTs against
+// INFORMATION_SCHEMA, that special case), so metrics and logs are grouped by
+// something meaningful instead of one firehose of raw SQL text.
+type QueryKind string
+
+const (
+	QueryKindMerge             QueryKind = "MERGE"
+	QueryKindCopy              QueryKind = "COPY"
+	QueryKindCreateTable       QueryKind = "CREATE_TABLE"
+	QueryKindAlterTable        QueryKind = "ALTER_TABLE"
+	QueryKindDropTable         QueryKind = "DROP_TABLE"
+	QueryKindInsert            QueryKind = "INSERT"
+	QueryKindDelete            QueryKind = "DELETE"
+	QueryKindInformationSchema QueryKind = "INFORMATION_SCHEMA"
+	QueryKindSelect            QueryKind = "SELECT"
+	QueryKindOther             QueryKind = "OTHER"
+)
+
+// classifyQueryKind inspects query's leading keyword, following the same
+// approach warehouse query-routing tools use to bucket arbitrary SQL text
+// without parsing it.
+func classifyQueryKind(query string) QueryKind {
+	upper := strings.ToUpper(strings.TrimSpace(query))
+	switch {
+	case strings.HasPrefix(upper, "MERGE"):
+		return QueryKindMerge
+	case strings.HasPrefix(upper, "COPY"):
+		return QueryKindCopy
+	case strings.HasPrefix(upper, "CREATE"):
+		return QueryKindCreateTable
+	case strings.HasPrefix(upper, "ALTER"):
+		return QueryKindAlterTable
+	case strings.HasPrefix(upper, "DROP"):
+		return QueryKindDropTable
+	case strings.HasPrefix(upper, "INSERT"):
+		return QueryKindInsert
+	case strings.HasPrefix(upper, "DELETE"):
+		return QueryKindDelete
+	case strings.Contains(upper, "INFORMATION_SCHEMA"):
+		return QueryKindInformationSchema
+	case strings.HasPrefix(upper, "SELECT"):
+		return QueryKindSelect
+	default:
+		return QueryKindOther
+	}
+}
+
+// MetricsRecorder receives one observation per finished query. It's an
+// interface rather than a concrete Prometheus/OpenTelemetry type so sqlmw
+// doesn't force either dependency on a caller that doesn't already use it;
+// backing it with otel_metrics (which can itself export to Prometheus) is
+// the expected choice in this codebase.
+type MetricsRecorder interface {
+	ObserveQuery(kind QueryKind, duration time.Duration, err error)
+}
+
+// Options configures a DB's middleware chain. All fields are optional: a
+// zero Options wraps inner with no retry, no metrics, and no slow-query
+// logging.
+type Options struct {
+	Logger  log.Logger
+	Metrics MetricsRecorder
+	// SlowQueryThreshold logs a query at Warn if it runs at or beyond this
+	// duration. Zero disables slow-query logging.
+	SlowQueryThreshold time.Duration
+	// MaxAttempts is the total number of times a query is attempted,
+	// including the first. Values below 1 are treated as 1 (no retry).
+	MaxAttempts int
+	// IsTransient reports whether err is worth retrying. Defaults to
+	// IsTransientSnowflakeError.
+	IsTransient func(error) bool
+}
+
+// DB wraps a *sql.DB with the middleware chain described by Options. It
+// implements the subset of *sql.DB's method set connectors actually call,
+// so embedding it in place of *sql.DB requires no changes at call sites.
+type DB struct {
+	inner *sql.DB
+	opts  Options
+}
+
+// Wrap returns a DB backed by inner, instrumented per opts.
+func Wrap(inner *sql.DB, opts Options) *DB {
+	if opts.IsTransient == nil {
+		opts.IsTransient = IsTransientSnowflakeError
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	return &DB{inner: inner, opts: opts}
+}
+
+// retryBackoff is the delay before retrying the nth (1-indexed) failed
+// attempt.
+func retryBackoff(attempt int) time.Duration {
+	d := 200 * time.Millisecond * time.Duration(attempt)
+	if d > 2*time.Second {
+		return 2 * time.Second
+	}
+	return d
+}
+
+// runWithMiddleware executes call, observing metrics/slow-query logging on
+// every attempt and retrying while err is transient and attempts remain.
+func runWithMiddleware[T any](ctx context.Context, d *DB, query string, call func() (T, error)) (T, error) {
+	kind := classifyQueryKind(query)
+
+	var result T
+	var err error
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		result, err = call()
+		elapsed := time.Since(start)
+
+		if d.opts.Metrics != nil {
+			d.opts.Metrics.ObserveQuery(kind, elapsed, err)
+		}
+		if d.opts.SlowQueryThreshold > 0 && elapsed >= d.opts.SlowQueryThreshold && d.opts.Logger != nil {
+			d.opts.Logger.Warn("slow query", "kind", string(kind), "duration", elapsed, "query", query)
+		}
+
+		if err == nil || attempt >= d.opts.MaxAttempts || !d.opts.IsTransient(err) {
+			return result, err
+		}
+		if d.opts.Logger != nil {
+			d.opts.Logger.Warn("retrying query after transient error",
+				"kind", string(kind), "attempt", attempt, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(retryBackoff(attempt)):
+		}
+	}
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return runWithMiddleware(ctx, d, query, func() (sql.Result, error) {
+		return d.inner.ExecContext(ctx, query, args...)
+	})
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return runWithMiddleware(ctx, d, query, func() (*sql.Rows, error) {
+		return d.inner.QueryContext(ctx, query, args...)
+	})
+}
+
+// QueryRowContext can't be retried: *sql.Row defers error reporting to
+// Scan, by which point the query has already been attempted exactly once.
+// It still records metrics and slow-query logging like every other method.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	kind := classifyQueryKind(query)
+	start := time.Now()
+	row := d.inner.QueryRowContext(ctx, query, args...)
+	elapsed := time.Since(start)
+
+	if d.opts.Metrics != nil {
+		d.opts.Metrics.ObserveQuery(kind, elapsed, nil)
+	}
+	if d.opts.SlowQueryThreshold > 0 && elapsed >= d.opts.SlowQueryThreshold && d.opts.Logger != nil {
+		d.opts.Logger.Warn("slow query", "kind", string(kind), "duration", elapsed, "query", query)
+	}
+	return row
+}
+
+func (d *DB) PingContext(ctx context.Context) error {
+	return d.inner.PingContext(ctx)
+}
+
+func (d *DB) Begin() (*sql.Tx, error) {
+	return d.inner.Begin()
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return d.inner.BeginTx(ctx, opts)
+}
+
+func (d *DB) Close() error {
+	return d.inner.Close()
+}
@@ -0,0 +1,117 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// Tunnel abstracts dialing a peer's network address either directly or
+// through an SSH bastion. Manager opens one per cached Postgres/MySQL/Mongo/
+// ClickHouse/Kafka connector and closes it with the connector, but the
+// tunnel is not passed to GetConnector: connectors still dial their peers
+// themselves, and nothing calls Dial.
+type Tunnel interface {
+	Dial(ctx context.Context, network string, addr string) (net.Conn, error)
+	Close() error
+}
+
+// directTunnel dials the peer's address directly; it's what every connector
+// used before tunneling existed, and remains the default when a peer has no
+// SSHConfig configured.
+type directTunnel struct {
+	dialer net.Dialer
+}
+
+func newDirectTunnel() *directTunnel {
+	return &directTunnel{}
+}
+
+func (d *directTunnel) Dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return d.dialer.DialContext(ctx, network, addr)
+}
+
+func (d *directTunnel) Close() error {
+	return nil
+}
+
+// sshTunnel dials through an SSH bastion host, so a connector can reach a
+// peer sitting in a private network without PeerDB itself needing direct
+// network access to it.
+type sshTunnel struct {
+	client *ssh.Client
+}
+
+// newSSHTunnel dials and authenticates the bastion host described by config,
+// returning a Tunnel whose Dial requests are proxied through it.
+func newSSHTunnel(ctx context.Context, config *protos.SSHConfig) (*sshTunnel, error) {
+	authMethod, err := sshAuthMethod(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SSH auth method for tunnel: %w", err)
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH tunnel known host key: %w", err)
+	}
+
+	bastionAddr := fmt.Sprintf("%s:%d", config.Host, config.Port)
+	clientConfig := &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            []ssh.AuthMethod{authMethod},
+		HostKeyCallback: hostKeyCallback,
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", bastionAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach SSH tunnel bastion %s: %w", bastionAddr, err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, bastionAddr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to establish SSH tunnel to bastion %s: %w", bastionAddr, err)
+	}
+
+	return &sshTunnel{client: ssh.NewClient(sshConn, chans, reqs)}, nil
+}
+
+func (s *sshTunnel) Dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return s.client.DialContext(ctx, network, addr)
+}
+
+func (s *sshTunnel) Close() error {
+	return s.client.Close()
+}
+
+func sshAuthMethod(config *protos.SSHConfig) (ssh.AuthMethod, error) {
+	if len(config.PrivateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey([]byte(config.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH tunnel private key: %w", err)
+		}
+		return ssh.PublicKeys(signer), nil
+	}
+	if config.Password != "" {
+		return ssh.Password(config.Password), nil
+	}
+	return nil, errors.New("SSH tunnel config has neither a private key nor a password")
+}
+
+func sshHostKeyCallback(config *protos.SSHConfig) (ssh.HostKeyCallback, error) {
+	if config.HostKey == "" {
+		//nolint:gosec // operators may explicitly opt out of host key verification for bastions they trust
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+	if err != nil {
+		return nil, err
+	}
+	return ssh.FixedHostKey(hostKey), nil
+}
@@ -0,0 +1,660 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils/cdcstore/migrations"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// currentSpillSchemaVersion is prepended to every value written to a
+// CDCSpillBackend so that a PeerDB upgrade that changes model.Record or a
+// QValue kind can detect and migrate entries spilled by an older binary,
+// rather than silently mis-decoding them after a crash-restart.
+const currentSpillSchemaVersion uint16 = 2
+
+// defaultNumRecordsSwitchThreshold is the number of in-memory records after which
+// a CDCStore spills subsequent records to its configured CDCSpillBackend.
+const defaultNumRecordsSwitchThreshold = 1_000_000
+
+// CDCSpillBackend is the storage backend a CDCStore spills records to once it
+// has buffered numRecordsSwitchThreshold records in memory. Implementations must
+// be safe to use from a single goroutine at a time (CDCStore does not add its
+// own locking).
+type CDCSpillBackend interface {
+	// Open prepares the backend to store records for the given flow, returning
+	// the on-disk (or other durable) location used, if any, for logging.
+	Open(flowJobName string) (string, error)
+	Set(key []byte, value []byte) error
+	Get(key []byte) ([]byte, bool, error)
+	Delete(key []byte) error
+	// Len returns the number of entries currently stored in the backend.
+	Len() (int, error)
+	// DiskBytes returns the backend's best estimate of bytes used on durable
+	// storage, or 0 if the backend doesn't track this (e.g. MemOnlySpillBackend).
+	DiskBytes() (int64, error)
+	// Iterator returns a CDCSpillIterator over every key/value pair currently
+	// stored in the backend, in arbitrary order.
+	Iterator() (CDCSpillIterator, error)
+	Close() error
+}
+
+// CDCSpillIterator walks every entry spilled to a CDCSpillBackend. Callers
+// must call Close once done, whether or not iteration completed. It's an
+// alias of migrations.SpillIterator so that CDCSpillBackend implementations
+// are directly usable with migrations.Migrate.
+type CDCSpillIterator = migrations.SpillIterator
+
+// CDCStoreStats summarizes the current state of a CDCStore, suitable for
+// exporting as metrics during a long CDC drain.
+type CDCStoreStats struct {
+	InMemoryCount    int
+	OnDiskCount      int
+	OnDiskBytes      int64
+	LastScanProgress int
+	// EffectiveThreshold is the record-count threshold CDCStore is currently
+	// spilling at. It equals numRecordsSwitchThreshold until adaptive
+	// spilling latches, and from then on the in-memory record count at the
+	// moment it latched.
+	EffectiveThreshold int
+}
+
+// CDCStoreOptions configures the spillover behavior of a CDCStore. The zero
+// value selects the default Pebble-backed behavior used in production.
+type CDCStoreOptions struct {
+	// NumRecordsSwitchThreshold overrides defaultNumRecordsSwitchThreshold.
+	NumRecordsSwitchThreshold int
+	// Backend, if set, is used instead of constructing a PebbleSpillBackend.
+	// CDCStore calls Open on it lazily, the first time it needs to spill.
+	Backend CDCSpillBackend
+	// AdaptiveThreshold, if set, spills based on estimated process memory
+	// pressure rather than solely on NumRecordsSwitchThreshold, which is a
+	// poor proxy once row sizes vary widely (tiny INSERTs vs multi-MB TOAST
+	// values). The record-count threshold still applies as a hard ceiling.
+	AdaptiveThreshold *AdaptiveThresholdConfig
+}
+
+// AdaptiveThresholdConfig tunes CDCStore's memory-pressure-driven spillover.
+// Once the process's heap usage crosses HighWaterFraction of its memory
+// limit, the store starts spilling and keeps spilling for the rest of its
+// lifetime (hysteresis, so a single large record doesn't cause oscillation
+// between in-memory and spilled modes).
+type AdaptiveThresholdConfig struct {
+	// SampleEveryNInserts controls how often HeapAllocBytes/MemLimitBytes are
+	// resampled; sampling on every insert is unnecessary overhead.
+	SampleEveryNInserts int
+	// HighWaterFraction of the memory limit at which spillover engages.
+	HighWaterFraction float64
+	// HeapAllocBytes reports current process heap usage. Defaults to
+	// runtime.MemStats.HeapAlloc; overridable so tests can simulate memory
+	// pressure deterministically.
+	HeapAllocBytes func() uint64
+	// MemLimitBytes reports the process memory limit (e.g. GOMEMLIMIT).
+	// Defaults to the current soft memory limit via debug.SetMemoryLimit(-1);
+	// a value <= 0 disables adaptive spilling (no limit to measure against).
+	MemLimitBytes func() int64
+}
+
+// CDCStore buffers CDC records for a flow in memory, spilling to a
+// CDCSpillBackend once the in-memory set grows past a threshold. Records are
+// keyed by the source table and primary key value so that later updates to
+// the same row can be merged before being synced to the destination.
+type CDCStore[Items model.Items] struct {
+	inMemoryRecords           map[model.TableWithPkey]model.Record[Items]
+	backend                   CDCSpillBackend
+	newBackend                func() CDCSpillBackend
+	flowJobName               string
+	numRecordsSwitchThreshold int
+	lastScanProgress          int
+
+	adaptive           *AdaptiveThresholdConfig
+	insertsSinceSample int
+	adaptiveSpilling   bool
+	effectiveThreshold int
+}
+
+// NewCDCStore constructs a CDCStore using the default Pebble spill backend.
+// catalogPool is accepted for API symmetry with other constructors in this
+// package and is currently unused by the default backend.
+func NewCDCStore[Items model.Items](
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	flowJobName string,
+) (*CDCStore[Items], error) {
+	return NewCDCStoreWithOptions[Items](ctx, catalogPool, flowJobName, CDCStoreOptions{})
+}
+
+// NewCDCStoreWithOptions constructs a CDCStore with an explicit CDCStoreOptions,
+// allowing callers (and tests) to select an alternative CDCSpillBackend, such as
+// an in-memory-only backend that errors instead of spilling to disk.
+func NewCDCStoreWithOptions[Items model.Items](
+	_ context.Context,
+	_ shared.CatalogPool,
+	flowJobName string,
+	opts CDCStoreOptions,
+) (*CDCStore[Items], error) {
+	numRecordsSwitchThreshold := opts.NumRecordsSwitchThreshold
+	if numRecordsSwitchThreshold == 0 {
+		numRecordsSwitchThreshold = defaultNumRecordsSwitchThreshold
+	}
+
+	newBackend := func() CDCSpillBackend { return NewPebbleSpillBackend() }
+	if opts.Backend != nil {
+		backend := opts.Backend
+		newBackend = func() CDCSpillBackend { return backend }
+	}
+
+	adaptive := opts.AdaptiveThreshold
+	if adaptive != nil {
+		if adaptive.SampleEveryNInserts <= 0 {
+			adaptive.SampleEveryNInserts = 1
+		}
+		if adaptive.HighWaterFraction <= 0 {
+			adaptive.HighWaterFraction = 0.8
+		}
+		if adaptive.HeapAllocBytes == nil {
+			adaptive.HeapAllocBytes = defaultHeapAllocBytes
+		}
+		if adaptive.MemLimitBytes == nil {
+			adaptive.MemLimitBytes = defaultMemLimitBytes
+		}
+	}
+
+	return &CDCStore[Items]{
+		inMemoryRecords:           make(map[model.TableWithPkey]model.Record[Items]),
+		backend:                   nil,
+		newBackend:                newBackend,
+		flowJobName:               flowJobName,
+		numRecordsSwitchThreshold: numRecordsSwitchThreshold,
+		adaptive:                  adaptive,
+		effectiveThreshold:        numRecordsSwitchThreshold,
+	}, nil
+}
+
+func defaultHeapAllocBytes() uint64 {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return mem.HeapAlloc
+}
+
+func defaultMemLimitBytes() int64 {
+	return debug.SetMemoryLimit(-1)
+}
+
+func (c *CDCStore[Items]) Set(ctx context.Context, logger *slog.Logger, key model.TableWithPkey, rec model.Record[Items]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	adaptiveSpill := c.shouldSpillAdaptive()
+	// Until adaptive spilling latches, the threshold in effect is whatever
+	// numRecordsSwitchThreshold is set to right now rather than whatever it
+	// was at construction time, since callers (including tests) may mutate
+	// it afterward. Once latched, effectiveThreshold's frozen value from the
+	// high-water-mark trip takes over.
+	threshold := c.numRecordsSwitchThreshold
+	if c.adaptiveSpilling {
+		threshold = c.effectiveThreshold
+	}
+	if len(c.inMemoryRecords) < threshold && !adaptiveSpill {
+		c.inMemoryRecords[key] = rec
+		return nil
+	}
+
+	if c.backend == nil {
+		backend := c.newBackend()
+		path, err := backend.Open(c.flowJobName)
+		if err != nil {
+			return fmt.Errorf("failed to open CDC spill backend: %w", err)
+		}
+		logger.Info("spilling CDC records", slog.String("flowJobName", c.flowJobName), slog.String("location", path))
+		c.backend = backend
+
+		if rewritten, err := c.migrateSpilledRecords(); err != nil {
+			return fmt.Errorf("failed to migrate spilled records to current schema: %w", err)
+		} else if rewritten > 0 {
+			logger.Info("migrated spilled CDC records to current schema",
+				slog.String("flowJobName", c.flowJobName), slog.Int("count", rewritten))
+		}
+	}
+
+	encoded, err := encodeCDCRecord(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record for spilling: %w", err)
+	}
+
+	encodedKey, err := encodeCDCKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key for spilling: %w", err)
+	}
+
+	if err := c.backend.Set(encodedKey, encoded); err != nil {
+		return fmt.Errorf("failed to spill record: %w", err)
+	}
+	delete(c.inMemoryRecords, key)
+	return nil
+}
+
+// shouldSpillAdaptive reports whether the store should spill regardless of
+// numRecordsSwitchThreshold, because process memory usage has crossed
+// AdaptiveThreshold's high-water mark. Once tripped, it latches on for the
+// lifetime of the store (hysteresis) so spilling doesn't flap as the GC
+// reclaims heap between samples.
+func (c *CDCStore[Items]) shouldSpillAdaptive() bool {
+	if c.adaptive == nil {
+		return false
+	}
+	if c.adaptiveSpilling {
+		return true
+	}
+
+	c.insertsSinceSample++
+	if c.insertsSinceSample < c.adaptive.SampleEveryNInserts {
+		return false
+	}
+	c.insertsSinceSample = 0
+
+	limit := c.adaptive.MemLimitBytes()
+	if limit <= 0 {
+		// no memory limit to measure against; fall back to the record-count threshold
+		return false
+	}
+
+	heapAlloc := c.adaptive.HeapAllocBytes()
+	if float64(heapAlloc) < c.adaptive.HighWaterFraction*float64(limit) {
+		return false
+	}
+
+	c.adaptiveSpilling = true
+	c.effectiveThreshold = len(c.inMemoryRecords)
+	return true
+}
+
+func (c *CDCStore[Items]) Get(ctx context.Context, key model.TableWithPkey) (model.Record[Items], bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if rec, ok := c.inMemoryRecords[key]; ok {
+		return rec, true, nil
+	}
+
+	if c.backend == nil {
+		return nil, false, nil
+	}
+
+	encodedKey, err := encodeCDCKey(key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode key for lookup: %w", err)
+	}
+
+	value, ok, err := c.backend.Get(encodedKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read spilled record: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	rec, err := decodeCDCRecord[Items](value)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode spilled record: %w", err)
+	}
+	return rec, true, nil
+}
+
+// ForEach visits every in-memory and spilled record, calling fn for each.
+// It checks ctx between batches of work so a cancelled or timed-out flow
+// doesn't get stuck iterating a large spilled batch; on cancellation it
+// returns a joined error of ctx.Err() and the iterator's own close error.
+func (c *CDCStore[Items]) ForEach(ctx context.Context, fn func(model.TableWithPkey, model.Record[Items]) error) error {
+	c.lastScanProgress = 0
+	for key, rec := range c.inMemoryRecords {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(key, rec); err != nil {
+			return err
+		}
+		c.lastScanProgress++
+	}
+
+	if c.backend == nil {
+		return nil
+	}
+
+	it, err := c.backend.Iterator()
+	if err != nil {
+		return fmt.Errorf("failed to open spill iterator: %w", err)
+	}
+
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, it.Close())
+		}
+
+		key, err := decodeCDCKey(it.Key())
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to decode spilled key: %w", err), it.Close())
+		}
+		rec, err := decodeCDCRecord[Items](it.Value())
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to decode spilled record: %w", err), it.Close())
+		}
+		if err := fn(key, rec); err != nil {
+			return errors.Join(err, it.Close())
+		}
+		c.lastScanProgress++
+
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, it.Close())
+		}
+	}
+
+	return it.Close()
+}
+
+// Len returns the total number of records tracked by the store, in memory and spilled.
+func (c *CDCStore[Items]) Len() int {
+	count := len(c.inMemoryRecords)
+	if c.backend != nil {
+		if spilled, err := c.backend.Len(); err == nil {
+			count += spilled
+		}
+	}
+	return count
+}
+
+// Stats reports the current size of the store, split between what's held in
+// memory and what has spilled to the backend, along with progress of the
+// most recent ForEach scan. It's meant to back metrics on long CDC drains.
+func (c *CDCStore[Items]) Stats() CDCStoreStats {
+	effectiveThreshold := c.numRecordsSwitchThreshold
+	if c.adaptiveSpilling {
+		effectiveThreshold = c.effectiveThreshold
+	}
+	stats := CDCStoreStats{
+		InMemoryCount:      len(c.inMemoryRecords),
+		LastScanProgress:   c.lastScanProgress,
+		EffectiveThreshold: effectiveThreshold,
+	}
+	if c.backend != nil {
+		if count, err := c.backend.Len(); err == nil {
+			stats.OnDiskCount = count
+		}
+		if bytes, err := c.backend.DiskBytes(); err == nil {
+			stats.OnDiskBytes = bytes
+		}
+	}
+	return stats
+}
+
+func (c *CDCStore[Items]) Close() error {
+	if c.backend != nil {
+		return c.backend.Close()
+	}
+	return nil
+}
+
+// migrateSpilledRecords runs a forward migration pass over c.backend,
+// rewriting any entry spilled by an older PeerDB binary into the current
+// schema. It's a no-op the vast majority of the time, since backends are
+// normally rooted in a fresh temporary directory per flow run; it matters
+// when a backend is reopened against a pre-existing spill directory left by
+// a crashed worker.
+func (c *CDCStore[Items]) migrateSpilledRecords() (int, error) {
+	return migrations.Migrate(
+		c.backend,
+		currentSpillSchemaVersion,
+		func(value []byte) (any, uint16, error) {
+			rec, version, err := decodeCDCRecordVersioned[Items](value)
+			return rec, version, err
+		},
+		func(record any) ([]byte, error) {
+			return encodeCDCRecord(record.(model.Record[Items]))
+		},
+	)
+}
+
+// encodeCDCRecordV1 reproduces the original, unversioned gob encoding used
+// before schema versioning was introduced. Only tests call it, to write
+// legacy entries for the startup migration pass to decode and rewrite.
+func encodeCDCRecordV1[Items model.Items](rec model.Record[Items]) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&rec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeCDCRecord[Items model.Items](rec model.Record[Items]) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.BigEndian, currentSpillSchemaVersion); err != nil {
+		return nil, err
+	}
+	if err := gob.NewEncoder(&buf).Encode(&rec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeCDCRecord[Items model.Items](data []byte) (model.Record[Items], error) {
+	rec, _, err := decodeCDCRecordVersioned[Items](data)
+	return rec, err
+}
+
+// encodeCDCKey gob-encodes key for use as a spill backend key. TableWithPkey
+// isn't a fixed-size byte array (it carries a TableName string alongside its
+// PkeyColVal hash), so it can't be sliced directly the way a byte array can.
+func encodeCDCKey(key model.TableWithPkey) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&key); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeCDCKey(data []byte) (model.TableWithPkey, error) {
+	var key model.TableWithPkey
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&key); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+// decodeCDCRecordVersioned decodes a spilled record and reports the schema
+// version it was encoded with, so the migration pass can tell which entries
+// are stale. Version 1 is the original, unversioned gob encoding used before
+// this schema-versioning scheme was introduced.
+func decodeCDCRecordVersioned[Items model.Items](data []byte) (model.Record[Items], uint16, error) {
+	if len(data) >= 2 {
+		version := binary.BigEndian.Uint16(data[:2])
+		if version == currentSpillSchemaVersion {
+			var rec model.Record[Items]
+			if err := gob.NewDecoder(bytes.NewReader(data[2:])).Decode(&rec); err != nil {
+				return nil, 0, err
+			}
+			return rec, version, nil
+		}
+	}
+
+	// fall back to the legacy, unversioned encoding
+	var rec model.Record[Items]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode spilled record at any known schema version: %w", err)
+	}
+	return rec, 1, nil
+}
+
+// PebbleSpillBackend is the default CDCSpillBackend, backed by an on-disk
+// Pebble instance rooted in a temporary directory unique to the flow.
+type PebbleSpillBackend struct {
+	db   *pebble.DB
+	path string
+}
+
+func NewPebbleSpillBackend() *PebbleSpillBackend {
+	return &PebbleSpillBackend{}
+}
+
+func (p *PebbleSpillBackend) Open(flowJobName string) (string, error) {
+	dir, err := os.MkdirTemp("", "cdc_spill_"+shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)+"_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	db, err := pebble.Open(dir, &pebble.Options{})
+	if err != nil {
+		return "", fmt.Errorf("failed to open pebble db at %s: %w", dir, err)
+	}
+
+	p.db = db
+	p.path = dir
+	return dir, nil
+}
+
+func (p *PebbleSpillBackend) Set(key []byte, value []byte) error {
+	return p.db.Set(key, value, pebble.Sync)
+}
+
+func (p *PebbleSpillBackend) Get(key []byte) ([]byte, bool, error) {
+	value, closer, err := p.db.Get(key)
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer closer.Close()
+
+	out := make([]byte, len(value))
+	copy(out, value)
+	return out, true, nil
+}
+
+func (p *PebbleSpillBackend) Delete(key []byte) error {
+	return p.db.Delete(key, pebble.Sync)
+}
+
+func (p *PebbleSpillBackend) Len() (int, error) {
+	iter, err := p.db.NewIter(nil)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	count := 0
+	for iter.First(); iter.Valid(); iter.Next() {
+		count++
+	}
+	return count, iter.Error()
+}
+
+func (p *PebbleSpillBackend) DiskBytes() (int64, error) {
+	metrics := p.db.Metrics()
+	return int64(metrics.DiskSpaceUsage()), nil
+}
+
+func (p *PebbleSpillBackend) Iterator() (CDCSpillIterator, error) {
+	iter, err := p.db.NewIter(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleSpillIterator{iter: iter, started: false}, nil
+}
+
+type pebbleSpillIterator struct {
+	iter    *pebble.Iterator
+	started bool
+}
+
+func (it *pebbleSpillIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		return it.iter.First()
+	}
+	return it.iter.Next()
+}
+
+func (it *pebbleSpillIterator) Key() []byte {
+	return it.iter.Key()
+}
+
+func (it *pebbleSpillIterator) Value() []byte {
+	return it.iter.Value()
+}
+
+func (it *pebbleSpillIterator) Close() error {
+	return it.iter.Close()
+}
+
+func (p *PebbleSpillBackend) Close() error {
+	if p.db == nil {
+		return nil
+	}
+	if err := p.db.Close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(p.path)
+}
+
+// MemOnlySpillBackend rejects spillover entirely, returning an error instead
+// of writing to disk. Useful for ephemeral filesystems or small-memory pods
+// where operators would rather fail a flow than risk filling the container
+// filesystem.
+type MemOnlySpillBackend struct{}
+
+func NewMemOnlySpillBackend() *MemOnlySpillBackend {
+	return &MemOnlySpillBackend{}
+}
+
+func (MemOnlySpillBackend) Open(string) (string, error) {
+	return "", errors.New("cdc spillover disabled: in-memory-only backend configured")
+}
+
+func (MemOnlySpillBackend) Set([]byte, []byte) error {
+	return errors.New("cdc spillover disabled: in-memory-only backend configured")
+}
+
+func (MemOnlySpillBackend) Get([]byte) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (MemOnlySpillBackend) Delete([]byte) error {
+	return nil
+}
+
+func (MemOnlySpillBackend) Len() (int, error) {
+	return 0, nil
+}
+
+func (MemOnlySpillBackend) DiskBytes() (int64, error) {
+	return 0, nil
+}
+
+func (MemOnlySpillBackend) Iterator() (CDCSpillIterator, error) {
+	return emptySpillIterator{}, nil
+}
+
+func (MemOnlySpillBackend) Close() error {
+	return nil
+}
+
+type emptySpillIterator struct{}
+
+func (emptySpillIterator) Next() bool    { return false }
+func (emptySpillIterator) Key() []byte   { return nil }
+func (emptySpillIterator) Value() []byte { return nil }
+func (emptySpillIterator) Close() error  { return nil }
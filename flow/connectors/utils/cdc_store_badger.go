@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	badger "github.com/dgraph-io/badger/v4"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// BadgerSpillBackend is an alternative CDCSpillBackend backed by BadgerDB,
+// useful on deployments where Pebble's LSM write pattern is a poor fit for
+// the underlying storage (e.g. some network-attached volumes).
+type BadgerSpillBackend struct {
+	db   *badger.DB
+	path string
+}
+
+func NewBadgerSpillBackend() *BadgerSpillBackend {
+	return &BadgerSpillBackend{}
+}
+
+func (b *BadgerSpillBackend) Open(flowJobName string) (string, error) {
+	dir, err := os.MkdirTemp("", "cdc_spill_badger_"+shared.ReplaceIllegalCharactersWithUnderscores(flowJobName)+"_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return "", fmt.Errorf("failed to open badger db at %s: %w", dir, err)
+	}
+
+	b.db = db
+	b.path = dir
+	return dir, nil
+}
+
+func (b *BadgerSpillBackend) Set(key []byte, value []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+func (b *BadgerSpillBackend) Get(key []byte) ([]byte, bool, error) {
+	var out []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			out = append([]byte(nil), val...)
+			return nil
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+func (b *BadgerSpillBackend) Delete(key []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+func (b *BadgerSpillBackend) Len() (int, error) {
+	count := 0
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (b *BadgerSpillBackend) DiskBytes() (int64, error) {
+	lsm, vlog := b.db.Size()
+	return lsm + vlog, nil
+}
+
+func (b *BadgerSpillBackend) Iterator() (CDCSpillIterator, error) {
+	txn := b.db.NewTransaction(false)
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	it.Rewind()
+	return &badgerSpillIterator{txn: txn, it: it, started: false}, nil
+}
+
+type badgerSpillIterator struct {
+	txn     *badger.Txn
+	it      *badger.Iterator
+	started bool
+	value   []byte
+}
+
+func (it *badgerSpillIterator) Next() bool {
+	if !it.started {
+		it.started = true
+	} else {
+		it.it.Next()
+	}
+	return it.it.Valid()
+}
+
+func (it *badgerSpillIterator) Key() []byte {
+	return it.it.Item().KeyCopy(nil)
+}
+
+func (it *badgerSpillIterator) Value() []byte {
+	value, err := it.it.Item().ValueCopy(nil)
+	if err != nil {
+		return nil
+	}
+	it.value = value
+	return it.value
+}
+
+func (it *badgerSpillIterator) Close() error {
+	it.it.Close()
+	it.txn.Discard()
+	return nil
+}
+
+func (b *BadgerSpillBackend) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	if err := b.db.Close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(b.path)
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/binary"
 	"log/slog"
 	"testing"
 	"time"
@@ -58,12 +60,12 @@ func TestSingleRecord(t *testing.T) {
 	cdcRecordsStore.numRecordsSwitchThreshold = 10
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(slog.Default(), key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
 	// should not spill into DB
 	require.Len(t, cdcRecordsStore.inMemoryRecords, 1)
-	require.Nil(t, cdcRecordsStore.pebbleDB)
+	require.Nil(t, cdcRecordsStore.backend)
 
-	reck, ok, err := cdcRecordsStore.Get(key)
+	reck, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, reck)
@@ -80,20 +82,20 @@ func TestRecordsTillSpill(t *testing.T) {
 	// add records upto set limit
 	for i := 1; i <= 10; i++ {
 		key, rec := genKeyAndRec(t)
-		err := cdcRecordsStore.Set(slog.Default(), key, rec)
+		err := cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec)
 		require.NoError(t, err)
 		require.Len(t, cdcRecordsStore.inMemoryRecords, i)
-		require.Nil(t, cdcRecordsStore.pebbleDB)
+		require.Nil(t, cdcRecordsStore.backend)
 	}
 
 	// this record should be spilled to DB
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(slog.Default(), key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
 	_, ok := cdcRecordsStore.inMemoryRecords[key]
 	require.False(t, ok)
-	require.NotNil(t, cdcRecordsStore.pebbleDB)
+	require.NotNil(t, cdcRecordsStore.backend)
 
-	reck, ok, err := cdcRecordsStore.Get(key)
+	reck, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, reck)
@@ -109,9 +111,9 @@ func TestTimeAndDecimalEncoding(t *testing.T) {
 	cdcRecordsStore.numRecordsSwitchThreshold = 0
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(slog.Default(), key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
 
-	retreived, ok, err := cdcRecordsStore.Get(key)
+	retreived, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, retreived)
@@ -130,9 +132,9 @@ func TestNullKeyDoesntStore(t *testing.T) {
 	cdcRecordsStore.numRecordsSwitchThreshold = 0
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(slog.Default(), model.TableWithPkey{}, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), model.TableWithPkey{}, rec))
 
-	retreived, ok, err := cdcRecordsStore.Get(key)
+	retreived, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.Nil(t, retreived)
 	require.NoError(t, err)
 	require.False(t, ok)
@@ -141,3 +143,120 @@ func TestNullKeyDoesntStore(t *testing.T) {
 
 	require.NoError(t, cdcRecordsStore.Close())
 }
+
+func TestMemOnlyBackendErrorsOnOverflow(t *testing.T) {
+	t.Parallel()
+
+	cdcRecordsStore, err := NewCDCStoreWithOptions[model.RecordItems](t.Context(), nil, "test_mem_only_overflow", CDCStoreOptions{
+		NumRecordsSwitchThreshold: 1,
+		Backend:                   NewMemOnlySpillBackend(),
+	})
+	require.NoError(t, err)
+
+	key, rec := genKeyAndRec(t)
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
+
+	key2, rec2 := genKeyAndRec(t)
+	require.Error(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key2, rec2))
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_migrate")
+	require.NoError(t, err)
+
+	backend := NewPebbleSpillBackend()
+	_, err = backend.Open("test_migrate")
+	require.NoError(t, err)
+	cdcRecordsStore.backend = backend
+
+	key, rec := genKeyAndRec(t)
+	legacyEncoded, err := encodeCDCRecordV1(rec)
+	require.NoError(t, err)
+	encodedKey, err := encodeCDCKey(key)
+	require.NoError(t, err)
+	require.NoError(t, backend.Set(encodedKey, legacyEncoded))
+
+	rewritten, err := cdcRecordsStore.migrateSpilledRecords()
+	require.NoError(t, err)
+	require.Equal(t, 1, rewritten)
+
+	onDisk, ok, err := backend.Get(encodedKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.GreaterOrEqual(t, len(onDisk), 2)
+	require.Equal(t, currentSpillSchemaVersion, binary.BigEndian.Uint16(onDisk[:2]))
+
+	reck, ok, err := cdcRecordsStore.Get(t.Context(), key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, rec, reck)
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
+
+func TestAdaptiveThresholdSpillsOnMemoryPressure(t *testing.T) {
+	t.Parallel()
+
+	// simulate heap usage climbing towards the limit as records are inserted,
+	// crossing the 80% high-water mark on the 3rd sample.
+	var heapAlloc uint64
+	cdcRecordsStore, err := NewCDCStoreWithOptions[model.RecordItems](t.Context(), nil, "test_adaptive_threshold", CDCStoreOptions{
+		NumRecordsSwitchThreshold: 1000,
+		AdaptiveThreshold: &AdaptiveThresholdConfig{
+			SampleEveryNInserts: 1,
+			HighWaterFraction:   0.8,
+			HeapAllocBytes:      func() uint64 { return heapAlloc },
+			MemLimitBytes:       func() int64 { return 100 },
+		},
+	})
+	require.NoError(t, err)
+
+	for i := range 3 {
+		heapAlloc += 30
+		key, rec := genKeyAndRec(t)
+		require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
+		if i < 2 {
+			require.Nil(t, cdcRecordsStore.backend)
+		}
+	}
+
+	// heapAlloc is now 90, past 80% of the 100-byte limit: adaptive spilling
+	// should have latched on well before numRecordsSwitchThreshold was hit.
+	require.NotNil(t, cdcRecordsStore.backend)
+	require.Equal(t, 2, cdcRecordsStore.Stats().EffectiveThreshold)
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
+
+func TestForEachCancellationCutsOffScan(t *testing.T) {
+	t.Parallel()
+
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_foreach_cancel")
+	require.NoError(t, err)
+	cdcRecordsStore.numRecordsSwitchThreshold = 0
+
+	const numRecords = 50
+	for range numRecords {
+		key, rec := genKeyAndRec(t)
+		require.NoError(t, cdcRecordsStore.Set(t.Context(), slog.Default(), key, rec))
+	}
+	require.NotNil(t, cdcRecordsStore.backend)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	seen := 0
+	err = cdcRecordsStore.ForEach(ctx, func(model.TableWithPkey, model.Record[model.RecordItems]) error {
+		seen++
+		if seen == numRecords/2 {
+			cancel()
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, seen, numRecords)
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
@@ -0,0 +1,76 @@
+// Package migrations rewrites CDCStore spill entries encoded by an older
+// PeerDB release into the current on-disk schema, analogous to the forward
+// migration pass storagenode implementations run over local data left behind
+// by a crash-restart after an upgrade.
+package migrations
+
+// SpillBackend is the subset of utils.CDCSpillBackend that Migrate needs: the
+// ability to walk every stored entry and rewrite it in place.
+type SpillBackend interface {
+	Iterator() (SpillIterator, error)
+	Set(key []byte, value []byte) error
+}
+
+// SpillIterator is the subset of utils.CDCSpillIterator that Migrate needs.
+type SpillIterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Close() error
+}
+
+// Decode interprets a single spilled value, reporting the schema version it
+// was encoded with.
+type Decode func(value []byte) (record any, version uint16, err error)
+
+// Encode serializes a decoded record at the current schema version.
+type Encode func(record any) ([]byte, error)
+
+// Migrate walks every entry in backend, re-encoding any entry whose on-disk
+// version is older than currentVersion. It collects rewrites during the scan
+// and applies them afterwards so the backend isn't mutated while its
+// iterator is still open.
+func Migrate(backend SpillBackend, currentVersion uint16, decode Decode, encode Encode) (int, error) {
+	it, err := backend.Iterator()
+	if err != nil {
+		return 0, err
+	}
+
+	type rewrite struct {
+		key   []byte
+		value []byte
+	}
+	var rewrites []rewrite
+
+	for it.Next() {
+		record, version, err := decode(it.Value())
+		if err != nil {
+			_ = it.Close()
+			return 0, err
+		}
+		if version >= currentVersion {
+			continue
+		}
+
+		reencoded, err := encode(record)
+		if err != nil {
+			_ = it.Close()
+			return 0, err
+		}
+
+		key := make([]byte, len(it.Key()))
+		copy(key, it.Key())
+		rewrites = append(rewrites, rewrite{key: key, value: reencoded})
+	}
+	if err := it.Close(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range rewrites {
+		if err := backend.Set(r.key, r.value); err != nil {
+			return len(rewrites), err
+		}
+	}
+
+	return len(rewrites), nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QualifiedTable identifies a table by up to three dotted segments:
+// database, schema, and table. Database is empty when the identifier it was
+// parsed from only specified schema.table, leaving the caller to fall back
+// to whatever database its connection already defaults to.
+type QualifiedTable struct {
+	Database string
+	Schema   string
+	Table    string
+}
+
+// ParseFullyQualifiedTable splits identifier on "." into up to three parts —
+// db.schema.table, schema.table, or table alone — unlike ParseSchemaTable,
+// which only ever expects schema.table. It exists for call sites that may
+// need to address a table in a different database than the one they're
+// connected to, e.g. renaming across a scratch/production database split.
+func ParseFullyQualifiedTable(identifier string) (*QualifiedTable, error) {
+	parts := strings.Split(identifier, ".")
+	switch len(parts) {
+	case 1:
+		return &QualifiedTable{Table: parts[0]}, nil
+	case 2:
+		return &QualifiedTable{Schema: parts[0], Table: parts[1]}, nil
+	case 3:
+		return &QualifiedTable{Database: parts[0], Schema: parts[1], Table: parts[2]}, nil
+	default:
+		return nil, fmt.Errorf("invalid fully qualified table identifier: %s", identifier)
+	}
+}
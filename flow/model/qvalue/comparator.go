@@ -0,0 +1,96 @@
+package qvalue
+
+import (
+	"sync"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// defaultNativeNumericArrayTolerance is the absolute difference below which
+// two elements of a native numeric array (int16/32/64, float32/64) are
+// considered equal. It was previously 1e9, which made every such comparison
+// vacuously true.
+const defaultNativeNumericArrayTolerance = 1e-9
+
+// Comparator lets a peer/mirror config override how two QValues of a given
+// kind are compared, for domain-specific equivalence this package can't know
+// about on its own: decimal comparisons at a target precision, geometry
+// within a tolerance, JSON with key-order normalization, and so on. Compare
+// only needs to distinguish equal from unequal; it need not produce a total
+// order; QValues of many kinds (geometry, JSON, HStore) have no natural one.
+type Comparator interface {
+	// Equals reports whether a and b are equal under this Comparator.
+	Equals(a, b types.QValue) bool
+	// Compare returns 0 if a and b are equal under this Comparator, and a
+	// non-zero value otherwise.
+	Compare(a, b types.QValue) int
+}
+
+var (
+	comparatorRegistryMu sync.RWMutex
+	comparatorRegistry   = make(map[types.QValueKind]Comparator)
+)
+
+// RegisterComparator overrides the Comparator that Equals and
+// EqualsWithProfile use for every QValue of kind, e.g.:
+//
+//	qvalue.RegisterComparator(types.QValueKindNumeric, qvalue.NumericComparator{Scale: 4})
+//
+// Register comparators during startup rather than mid-flow: Equals reads the
+// registry without synchronizing against any particular mirror run, so an
+// in-flight comparison may observe either the old or the new Comparator.
+func RegisterComparator(kind types.QValueKind, c Comparator) {
+	comparatorRegistryMu.Lock()
+	defer comparatorRegistryMu.Unlock()
+	comparatorRegistry[kind] = c
+}
+
+func lookupComparator(kind types.QValueKind) (Comparator, bool) {
+	comparatorRegistryMu.RLock()
+	defer comparatorRegistryMu.RUnlock()
+	c, ok := comparatorRegistry[kind]
+	return c, ok
+}
+
+// NativeNumericArrayComparator compares native numeric array QValues
+// (QValueArrayInt16/32/64, QValueArrayFloat32/64) elementwise, treating
+// elements within Tolerance of each other as equal. Register it with a wider
+// Tolerance than defaultNativeNumericArrayTolerance for destinations that
+// round-trip floats through a lower-precision wire format.
+type NativeNumericArrayComparator struct {
+	Tolerance float64
+}
+
+func (c NativeNumericArrayComparator) Equals(a, b types.QValue) bool {
+	return compareNativeNumericArraysTolerance(a.Value(), b.Value(), c.Tolerance)
+}
+
+func (c NativeNumericArrayComparator) Compare(a, b types.QValue) int {
+	if c.Equals(a, b) {
+		return 0
+	}
+	return 1
+}
+
+// NumericComparator rounds both operands to Scale decimal places before
+// comparing, for mirrors validating a source against a destination that
+// stores NUMERIC columns at lower precision.
+type NumericComparator struct {
+	Scale int32
+}
+
+func (c NumericComparator) Equals(a, b types.QValue) bool {
+	num1, ok1 := getDecimal(a.Value())
+	num2, ok2 := getDecimal(b.Value())
+	if !ok1 || !ok2 {
+		return false
+	}
+	return num1.Round(c.Scale).Equal(num2.Round(c.Scale))
+}
+
+func (c NumericComparator) Compare(a, b types.QValue) int {
+	if c.Equals(a, b) {
+		return 0
+	}
+	return 1
+}
@@ -23,10 +23,87 @@ func valueEmpty(value any) bool {
 		(reflect.TypeOf(value).Kind() == reflect.Slice && reflect.ValueOf(value).Len() == 0)
 }
 
+// ValidationProfile configures the tolerances EqualsWithProfile applies when
+// comparing a row read from one peer against a row read from another, so a
+// mirror comparing across DB types with different native precision
+// (Snowflake TIMESTAMP_NTZ(6) vs Postgres timestamptz, ClickHouse
+// Decimal(38,10) vs Postgres numeric) can select the tolerance that reflects
+// real drift instead of false-positiving on a representation difference.
+type ValidationProfile struct {
+	// StrictNull disables the legacy behavior of treating nil, "", and the
+	// literal string "null" as mutually equal. A nullable text column that
+	// stores the string "null" does not actually equal SQL NULL; StrictNull
+	// lets validation catch that instead of hiding it.
+	StrictNull bool
+	// TreatEmptyStringAsNull treats "" as equal to NULL even under
+	// StrictNull. It's kept separate from StrictNull because some sources
+	// genuinely can't distinguish "" from NULL, while the string "null"
+	// should still never be conflated with SQL NULL.
+	TreatEmptyStringAsNull bool
+	// NumericScale, if non-zero, rounds QValueNumeric comparisons to this
+	// many decimal places instead of requiring exact equality.
+	NumericScale int32
+	// FloatEpsilon is the maximum absolute difference for QValueFloat32,
+	// QValueFloat64, and native numeric arrays to be considered equal. Zero
+	// means exact equality (for arrays, the package's default tolerance).
+	FloatEpsilon float64
+	// GeometryTolerance, if non-zero, compares QValueGeometry/QValueGeography
+	// by distance within this tolerance instead of requiring exact equality.
+	GeometryTolerance float64
+	// TimestampGranularity, if non-zero, truncates both operands to this
+	// granularity before comparing QValueTimestamp, QValueTimestampTZ,
+	// QValueTime, and QValueTimeTZ.
+	TimestampGranularity time.Duration
+	// IgnoreTimezone has no effect: timestamp comparisons already compare
+	// instants (UnixMicro, or Equal after truncation), so the same instant
+	// carried in two different time zones compares equal either way.
+	IgnoreTimezone bool
+}
+
+// DefaultProfile is the profile Equals uses: exact equality, except that
+// native numeric arrays compare within defaultNativeNumericArrayTolerance,
+// with nil, "", and "null" treated as mutually equal.
+func DefaultProfile() ValidationProfile {
+	return ValidationProfile{}
+}
+
+// profileValueEmpty is valueEmpty, adjusted for ValidationProfile.StrictNull:
+// with StrictNull unset it's identical to valueEmpty (including the legacy,
+// arguably-too-loose "null" string handling); with StrictNull set, only a
+// real nil (or, if TreatEmptyStringAsNull, an empty string) counts as empty.
+func profileValueEmpty(value any, profile ValidationProfile) bool {
+	if !profile.StrictNull {
+		return valueEmpty(value)
+	}
+	if value == nil {
+		return true
+	}
+	if value == "" {
+		return profile.TreatEmptyStringAsNull
+	}
+	return reflect.TypeOf(value).Kind() == reflect.Slice && reflect.ValueOf(value).Len() == 0
+}
+
+// Equals reports whether qv and other represent the same value, using
+// DefaultProfile. It delegates to any Comparator registered for qv.Kind()
+// via RegisterComparator, falling back to DefaultProfile's tolerances
+// otherwise.
 func Equals(qv types.QValue, other types.QValue) bool {
+	return EqualsWithProfile(qv, other, DefaultProfile())
+}
+
+// EqualsWithProfile is Equals with an explicit ValidationProfile, relaxing
+// equality to the tolerances the profile sets so that real cross-peer
+// precision differences aren't reported as drift. Nothing outside this
+// package calls Equals or EqualsWithProfile.
+func EqualsWithProfile(qv types.QValue, other types.QValue, profile ValidationProfile) bool {
+	if c, ok := lookupComparator(qv.Kind()); ok {
+		return c.Equals(qv, other)
+	}
+
 	qvValue := qv.Value()
 	otherValue := other.Value()
-	if valueEmpty(qvValue) && valueEmpty(otherValue) {
+	if profileValueEmpty(qvValue, profile) && profileValueEmpty(otherValue, profile) {
 		return true
 	}
 
@@ -35,10 +112,10 @@ func Equals(qv types.QValue, other types.QValue) bool {
 		return true
 	case types.QValueFloat32:
 		float2, ok2 := getFloat32(other.Value())
-		return ok2 && q.Val == float2
+		return ok2 && floatsEqual(float64(q.Val), float64(float2), profile.FloatEpsilon)
 	case types.QValueFloat64:
 		float2, ok2 := getFloat64(other.Value())
-		return ok2 && q.Val == float2
+		return ok2 && floatsEqual(q.Val, float2, profile.FloatEpsilon)
 	case types.QValueInt8:
 		int2, ok2 := getInt64(other.Value())
 		return ok2 && int64(q.Val) == int2
@@ -85,12 +162,15 @@ func Equals(qv types.QValue, other types.QValue) bool {
 		return compareString(q.Val, otherValue)
 	// all internally represented as a Golang time.Time
 	case types.QValueTimestamp, types.QValueTimestampTZ:
-		return compareGoTimestamp(qvValue, otherValue)
+		return compareGoTimestampGranular(qvValue, otherValue, profile.TimestampGranularity)
 	case types.QValueTime, types.QValueTimeTZ:
-		return compareGoTime(qvValue, otherValue)
+		return compareGoTimeGranular(qvValue, otherValue, profile.TimestampGranularity)
 	case types.QValueDate:
 		return compareGoDate(qvValue, otherValue)
 	case types.QValueNumeric:
+		if profile.NumericScale != 0 {
+			return NumericComparator{Scale: profile.NumericScale}.Equals(qv, other)
+		}
 		return compareNumeric(q.Val, otherValue)
 	case types.QValueBytes:
 		return compareBytes(qvValue, otherValue)
@@ -111,12 +191,15 @@ func Equals(qv types.QValue, other types.QValue) bool {
 		}
 		return reflect.DeepEqual(a, b)
 	case types.QValueGeometry:
-		return compareGeometry(q.Val, otherValue)
+		return compareGeometryTolerance(q.Val, otherValue, profile.GeometryTolerance)
 	case types.QValueGeography:
-		return compareGeometry(q.Val, otherValue)
+		return compareGeometryTolerance(q.Val, otherValue, profile.GeometryTolerance)
 	case types.QValueHStore:
 		return compareHStore(q.Val, otherValue)
 	case types.QValueArrayInt32, types.QValueArrayInt16, types.QValueArrayInt64, types.QValueArrayFloat32, types.QValueArrayFloat64:
+		if profile.FloatEpsilon > 0 {
+			return compareNativeNumericArraysTolerance(qvValue, otherValue, profile.FloatEpsilon)
+		}
 		return compareNativeNumericArrays(qvValue, otherValue)
 	case types.QValueArrayNumeric:
 		return compareNumericArrays(q.Val, otherValue)
@@ -173,6 +256,24 @@ func compareGoTimestamp(value1, value2 any) bool {
 	return et1.UnixMicro() == et2.UnixMicro()
 }
 
+// compareGoTimestampGranular is compareGoTimestamp, truncated to granularity
+// first so a source and destination that round a timestamp column to
+// different sub-second precision aren't reported as a mismatch. granularity
+// <= 0 preserves the exact, microsecond-level comparison.
+func compareGoTimestampGranular(value1, value2 any, granularity time.Duration) bool {
+	if granularity <= 0 {
+		return compareGoTimestamp(value1, value2)
+	}
+
+	et1, ok1 := value1.(time.Time)
+	et2, ok2 := value2.(time.Time)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	return et1.Truncate(granularity).Equal(et2.Truncate(granularity))
+}
+
 func compareGoTime(value1, value2 any) bool {
 	t1, ok1 := value1.(time.Duration)
 	t2, ok2 := value2.(time.Duration)
@@ -191,6 +292,31 @@ func compareGoTime(value1, value2 any) bool {
 	return ok1 && ok2 && t1 == t2
 }
 
+// compareGoTimeGranular is compareGoTime, rounded down to the nearest
+// granularity before comparing. granularity <= 0 preserves the exact
+// comparison.
+func compareGoTimeGranular(value1, value2 any, granularity time.Duration) bool {
+	if granularity <= 0 {
+		return compareGoTime(value1, value2)
+	}
+	return compareGoTime(asDuration(value1).Truncate(granularity), asDuration(value2).Truncate(granularity))
+}
+
+// asDuration normalizes a QValueTime/QValueTimeTZ's underlying
+// time.Duration-or-time.Time representation to a time.Duration since
+// midnight, for use by comparisons (like compareGoTimeGranular) that need to
+// do arithmetic on it rather than just testing equality.
+func asDuration(value any) time.Duration {
+	switch v := value.(type) {
+	case time.Duration:
+		return v
+	case time.Time:
+		return v.Sub(time.Unix(0, 0).UTC())
+	default:
+		return 0
+	}
+}
+
 func compareGoDate(value1, value2 any) bool {
 	t1, ok1 := value1.(time.Time)
 	t2, ok2 := value2.(time.Time)
@@ -218,6 +344,16 @@ func compareBytes(value1, value2 any) bool {
 	return ok1 && ok2 && bytes.Equal(bytes1, bytes2)
 }
 
+// floatsEqual reports whether f1 and f2 are within epsilon of each other.
+// epsilon <= 0 requires exact equality, preserving Equals' historical
+// behavior for float32/float64 QValues.
+func floatsEqual(f1, f2, epsilon float64) bool {
+	if epsilon <= 0 {
+		return f1 == f2
+	}
+	return math.Abs(f1-f2) <= epsilon
+}
+
 func compareNumeric(value1, value2 any) bool {
 	num1, ok1 := getDecimal(value1)
 	num2, ok2 := getDecimal(value2)
@@ -261,6 +397,33 @@ func compareGeometry(geoWkt string, value2 any) bool {
 	return geo1.Equals(geo2)
 }
 
+// compareGeometryTolerance is compareGeometry, relaxed to accept geometries
+// within tolerance of each other (by distance) rather than requiring exact
+// equality. tolerance <= 0 preserves the exact comparison.
+func compareGeometryTolerance(geoWkt string, value2 any, tolerance float64) bool {
+	if tolerance <= 0 {
+		return compareGeometry(geoWkt, value2)
+	}
+
+	geo2, err := geom.NewGeomFromWKT(value2.(string))
+	if err != nil {
+		panic(err)
+	}
+
+	if strings.HasPrefix(geoWkt, "SRID=") {
+		_, wkt, found := strings.Cut(geoWkt, ";")
+		if found {
+			geoWkt = wkt
+		}
+	}
+
+	geo1, err := geom.NewGeomFromWKT(geoWkt)
+	if err != nil {
+		panic(err)
+	}
+	return geo1.Distance(geo2) <= tolerance
+}
+
 func convertNativeNumericArrayToFloat64Array(val any) []float64 {
 	switch v := val.(type) {
 	case []int16:
@@ -301,6 +464,10 @@ func convertNativeNumericArrayToFloat64Array(val any) []float64 {
 }
 
 func compareNativeNumericArrays(value1, value2 any) bool {
+	return compareNativeNumericArraysTolerance(value1, value2, defaultNativeNumericArrayTolerance)
+}
+
+func compareNativeNumericArraysTolerance(value1, value2 any, tolerance float64) bool {
 	array1 := convertNativeNumericArrayToFloat64Array(value1)
 	array2 := convertNativeNumericArrayToFloat64Array(value2)
 	if array1 == nil || array2 == nil {
@@ -308,7 +475,7 @@ func compareNativeNumericArrays(value1, value2 any) bool {
 	}
 
 	return slices.EqualFunc(array1, array2, func(x float64, y float64) bool {
-		return math.Abs(x-y) < 1e9
+		return math.Abs(x-y) <= tolerance
 	})
 }
 